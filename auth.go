@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ewenson/sitemap_crawler/crawler"
+)
+
+// siteCredentials builds the credentials for the site's own hosts from the
+// -header, -basic-auth and -bearer-token flags. It returns nil if none are
+// set.
+func siteCredentials(headers []string, basicAuth, bearerToken string) (*crawler.HostCredentials, error) {
+	if len(headers) == 0 && basicAuth == "" && bearerToken == "" {
+		return nil, nil
+	}
+	if basicAuth != "" && bearerToken != "" {
+		return nil, fmt.Errorf("-basic-auth and -bearer-token cannot be used together")
+	}
+
+	creds := &crawler.HostCredentials{Header: make(http.Header), BearerToken: bearerToken}
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header %q, want 'Name: value'", h)
+		}
+		creds.Header.Add(name, strings.TrimSpace(value))
+	}
+	if basicAuth != "" {
+		var ok bool
+		if creds.Username, creds.Password, ok = strings.Cut(basicAuth, ":"); !ok || creds.Username == "" {
+			return nil, fmt.Errorf("invalid -basic-auth, want user:password")
+		}
+	}
+	return creds, nil
+}
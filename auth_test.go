@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// ---- siteCredentials ----------------------------------------------------
+
+func TestSiteCredentials(t *testing.T) {
+	t.Parallel()
+	creds, err := siteCredentials([]string{"X-Env: staging", "Accept-Language:sv", "X-Env: preview"}, "bot:s3:cret", "")
+	if err != nil {
+		t.Fatalf("siteCredentials() error = %v", err)
+	}
+	if got := creds.Header.Values("X-Env"); len(got) != 2 || got[0] != "staging" || got[1] != "preview" {
+		t.Errorf("X-Env = %q, want [staging preview]", got)
+	}
+	if got := creds.Header.Get("Accept-Language"); got != "sv" {
+		t.Errorf("Accept-Language = %q, want sv", got)
+	}
+	if creds.Username != "bot" || creds.Password != "s3:cret" {
+		t.Errorf("basic auth = %q:%q, want bot:s3:cret", creds.Username, creds.Password)
+	}
+
+	if creds, err := siteCredentials(nil, "", ""); creds != nil || err != nil {
+		t.Errorf("siteCredentials() without flags = %v, %v, want nil, nil", creds, err)
+	}
+}
+
+func TestSiteCredentials_Invalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		headers            []string
+		basic, token, want string
+	}{
+		{[]string{"X-Env staging"}, "", "", "invalid header"},
+		{[]string{": value"}, "", "", "invalid header"},
+		{[]string{"X Env: value"}, "", "", "invalid header"},
+		{nil, "bot", "", "invalid -basic-auth"},
+		{nil, ":secret", "", "invalid -basic-auth"},
+		{nil, "bot:secret", "token", "cannot be used together"},
+	}
+	for _, tt := range tests {
+		_, err := siteCredentials(tt.headers, tt.basic, tt.token)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("siteCredentials(%q, %q, %q) error = %v, want it to mention %q", tt.headers, tt.basic, tt.token, err, tt.want)
+		}
+	}
+}
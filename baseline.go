@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"ewenson/sitemap_crawler/crawler"
+)
+
+// baselineCommand implements "baseline", which writes a baseline file
+// accepting every problem in an earlier report. It returns the exit code.
+func baselineCommand(args []string) int {
+	fs := flag.NewFlagSet("baseline", flag.ContinueOnError)
+	days := fs.Int("days", 90, "Number of days until the entries expire, 0 for never")
+	output := fs.String("output", "-", "Baseline file to write, - for stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sitemap_crawler baseline [flags] <report.json|report.jsonl|report.csv>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitConfigError
+	}
+	if fs.NArg() != 1 || *days < 0 {
+		fs.Usage()
+		return exitConfigError
+	}
+
+	results, err := crawler.ReadReportFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCrawlFailed
+	}
+	var expires time.Time
+	if *days > 0 {
+		expires = time.Now().AddDate(0, 0, *days)
+	}
+	baseline := crawler.NewBaseline(results, expires)
+
+	if err := writeBaseline(*output, baseline); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing baseline: %v\n", err)
+		return exitCrawlFailed
+	}
+	fmt.Fprintf(os.Stderr, "Baseline with %d entries written to %s\n", len(baseline.Entries), *output)
+	return exitOK
+}
+
+// writeBaseline writes baseline to filename, or to stdout for "-".
+func writeBaseline(filename string, baseline *crawler.Baseline) error {
+	var w io.WriteCloser = nopCloser{os.Stdout}
+	if filename != "-" {
+		file, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		w = file
+	}
+	if err := baseline.Write(w); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// printExpired lists the baseline entries that no longer apply.
+func printExpired(baseline *crawler.Baseline, now time.Time) {
+	for _, e := range baseline.Expired(now) {
+		target := e.URL
+		if e.Page != "" {
+			target += " on " + e.Page
+		}
+		fmt.Fprintf(console, "Baseline entry for %s expired on %s, it is reported again\n", target, e.Expires)
+	}
+}
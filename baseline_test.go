@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ewenson/sitemap_crawler/crawler"
+)
+
+// ---- baselineCommand ----------------------------------------------------
+
+func TestBaselineCommand(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	reportFile := filepath.Join(dir, "report.json")
+	baselineFile := filepath.Join(dir, "baseline.yaml")
+
+	if err := crawler.WriteJSONReport(reportFile, failReport()); err != nil {
+		t.Fatalf("WriteJSONReport() error = %v", err)
+	}
+	if code := baselineCommand([]string{"-days", "30", "-output", baselineFile, reportFile}); code != exitOK {
+		t.Fatalf("baselineCommand() = %d, want %d", code, exitOK)
+	}
+
+	b, err := crawler.LoadBaseline(baselineFile)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error = %v", err)
+	}
+	// The 404, the 500, the request error and the missing anchor
+	if len(b.Entries) != 4 {
+		t.Errorf("got %d entries, want 4: %+v", len(b.Entries), b.Entries)
+	}
+	wantExpiry := time.Now().AddDate(0, 0, 30).Format("2006-01-02")
+	for _, e := range b.Entries {
+		if e.Expires != wantExpiry {
+			t.Errorf("entry for %s expires %q, want %q", e.URL, e.Expires, wantExpiry)
+		}
+	}
+}
+
+func TestBaselineCommand_Errors(t *testing.T) {
+	t.Parallel()
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{nil, exitConfigError},
+		{[]string{"-days", "-1", "report.json"}, exitConfigError},
+		{[]string{"-output", os.DevNull, missing}, exitCrawlFailed},
+	}
+	for _, tt := range tests {
+		if got := baselineCommand(tt.args); got != tt.want {
+			t.Errorf("baselineCommand(%q) = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
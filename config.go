@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sitesKey is the config file key holding the per-site profiles.
+const sitesKey = "sites"
+
+// configOnlyFlags are command line flags that make no sense inside a config
+// file.
+var configOnlyFlags = []string{"config", "site"}
+
+// loadConfig reads the YAML config file at filename and applies it to the
+// flags in fs. Every key is the name of a command line flag, e.g.
+//
+//	limit: 5
+//	check: [anchor, image]
+//	sites:
+//	  blog:
+//	    url: https://blog.example.com
+//	    timeout: 30s
+//
+// Values under sites.<site> override the top-level values when site is
+// given. Flags set on the command line override both, so loadConfig must be
+// called after fs has been parsed.
+func loadConfig(fs *flag.FlagSet, filename, site string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return applyConfig(fs, data, site)
+}
+
+// applyConfig applies the YAML config in data to the flags in fs that were
+// not set on the command line. See loadConfig.
+func applyConfig(fs *flag.FlagSet, data []byte, site string) error {
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+
+	var profile map[string]any
+	if site != "" {
+		sites, _ := values[sitesKey].(map[string]any)
+		p, ok := sites[site]
+		if !ok {
+			return fmt.Errorf("no site %q in config file", site)
+		}
+		if profile, ok = p.(map[string]any); !ok && p != nil {
+			return fmt.Errorf("site %q in config file must be a mapping", site)
+		}
+	}
+	delete(values, sitesKey)
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	// A profile replaces top-level values key by key, lists included
+	merged := maps.Clone(values)
+	if merged == nil {
+		merged = make(map[string]any)
+	}
+	maps.Copy(merged, profile)
+	for _, name := range slices.Sorted(maps.Keys(merged)) {
+		if explicit[name] {
+			continue
+		}
+		if err := setConfigFlag(fs, name, merged[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setConfigFlag sets the flag name to a config file value. Lists become
+// comma separated values, except for flags that may be repeated.
+func setConfigFlag(fs *flag.FlagSet, name string, value any) error {
+	if fs.Lookup(name) == nil || slices.Contains(configOnlyFlags, name) {
+		return fmt.Errorf("unknown config file option %q", name)
+	}
+
+	// Every item of a list is set separately on flags that collect values
+	if items, ok := value.([]any); ok {
+		if _, ok := fs.Lookup(name).Value.(*listFlag); ok {
+			for _, item := range items {
+				if err := fs.Set(name, fmt.Sprint(item)); err != nil {
+					return fmt.Errorf("config file option %q: %w", name, err)
+				}
+			}
+			return nil
+		}
+	}
+
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		s = strings.Join(items, ",")
+	case map[string]any:
+		return fmt.Errorf("config file option %q must not be a mapping", name)
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if err := fs.Set(name, s); err != nil {
+		return fmt.Errorf("config file option %q: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newConfigFlagSet returns a flag set with a few flags of every type, parsed
+// from args.
+func newConfigFlagSet(t *testing.T, args ...string) *flag.FlagSet {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.String("url", "", "")
+	fs.Int("limit", 10, "")
+	fs.Duration("timeout", time.Minute, "")
+	fs.Bool("verify", true, "")
+	fs.String("check", "anchor", "")
+	fs.Float64("rate", 0, "")
+	fs.String("config", "", "")
+	fs.Var(&listFlag{}, "exclude-links", "")
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return fs
+}
+
+const testConfig = `
+url: https://example.com
+limit: 5
+timeout: 30s
+verify: false
+check: [anchor, image]
+rate: 0.5
+exclude-links: ["*/wp-admin/*", "re:a{1,2}"]
+sites:
+  blog:
+    url: https://blog.example.com
+    limit: 2
+    exclude-links: ["*/drafts/*"]
+  empty:
+`
+
+func flagValue(fs *flag.FlagSet, name string) string {
+	return fs.Lookup(name).Value.String()
+}
+
+// ---- applyConfig --------------------------------------------------------
+
+func TestApplyConfig(t *testing.T) {
+	t.Parallel()
+	fs := newConfigFlagSet(t)
+	if err := applyConfig(fs, []byte(testConfig), ""); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	want := map[string]string{
+		"url":     "https://example.com",
+		"limit":   "5",
+		"timeout": "30s",
+		"verify":  "false",
+		"check":   "anchor,image",
+		"rate":    "0.5",
+	}
+	for name, value := range want {
+		if got := flagValue(fs, name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+
+	// Items of repeatable flags are kept apart, even if they hold commas
+	got := *fs.Lookup("exclude-links").Value.(*listFlag)
+	if want := (listFlag{"*/wp-admin/*", "re:a{1,2}"}); !slices.Equal(got, want) {
+		t.Errorf("exclude-links = %q, want %q", got, want)
+	}
+}
+
+func TestApplyConfig_SiteProfile(t *testing.T) {
+	t.Parallel()
+	fs := newConfigFlagSet(t)
+	if err := applyConfig(fs, []byte(testConfig), "blog"); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	for name, value := range map[string]string{"url": "https://blog.example.com", "limit": "2", "timeout": "30s"} {
+		if got := flagValue(fs, name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	// The profile's list replaces the top-level one
+	got := *fs.Lookup("exclude-links").Value.(*listFlag)
+	if want := (listFlag{"*/drafts/*"}); !slices.Equal(got, want) {
+		t.Errorf("exclude-links = %q, want %q", got, want)
+	}
+
+	if err := applyConfig(newConfigFlagSet(t), []byte(testConfig), "empty"); err != nil {
+		t.Errorf("applyConfig() with empty profile: unexpected error: %v", err)
+	}
+}
+
+func TestApplyConfig_FlagsOverrideFile(t *testing.T) {
+	t.Parallel()
+	fs := newConfigFlagSet(t, "-limit", "20", "-url", "https://other.example.com")
+	if err := applyConfig(fs, []byte(testConfig), "blog"); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if got := flagValue(fs, "limit"); got != "20" {
+		t.Errorf("limit = %q, want the command line value 20", got)
+	}
+	if got := flagValue(fs, "url"); got != "https://other.example.com" {
+		t.Errorf("url = %q, want the command line value", got)
+	}
+	if got := flagValue(fs, "timeout"); got != "30s" {
+		t.Errorf("timeout = %q, want the file value 30s", got)
+	}
+}
+
+func TestApplyConfig_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name, config, site, want string
+	}{
+		{"unknown option", "colour: blue", "", `unknown config file option "colour"`},
+		{"config only option", "config: other.yaml", "", `unknown config file option "config"`},
+		{"invalid value", "limit: many", "", `config file option "limit"`},
+		{"invalid yaml", "limit: [", "", "invalid config file"},
+		{"unknown site", testConfig, "shop", `no site "shop"`},
+		{"mapping value", "check: {a: b}", "", "must not be a mapping"},
+	}
+	for _, tt := range tests {
+		err := applyConfig(newConfigFlagSet(t), []byte(tt.config), tt.site)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+}
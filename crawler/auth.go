@@ -0,0 +1,194 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HostCredentials holds headers and credentials sent with every request to
+// some hosts, including each hop of a redirect chain that lands on them.
+// They are never sent to any other host, nor over another scheme or to
+// another port of the same host.
+type HostCredentials struct {
+	// Hosts lists the hosts the credentials are sent to, optionally with a
+	// scheme and port, e.g. https://api.example.com:8443. Without them the
+	// entrypoint's scheme and its default port are assumed. When empty the
+	// credentials are sent to the site's own hosts, i.e. the entrypoint's
+	// host and Options.AllowedHosts.
+	Hosts []string
+	// Header holds extra request headers.
+	Header http.Header
+	// Username and Password are sent as basic auth when Username is set.
+	Username string
+	Password string
+	// BearerToken is sent as an Authorization: Bearer header when set.
+	BearerToken string
+}
+
+// hostCredentials is HostCredentials resolved to a set of origins, see
+// requestOrigin.
+type hostCredentials struct {
+	origins map[string]bool
+	creds   HostCredentials
+}
+
+// authTransport is a RoundTripper that adds HostCredentials to the requests
+// going to their hosts.
+type authTransport struct {
+	base  http.RoundTripper
+	rules []hostCredentials
+}
+
+// newAuthTransport returns base adding creds to requests. Credentials
+// without hosts of their own go to the entrypoint's host and siteHosts.
+func newAuthTransport(base http.RoundTripper, entrypoint string, siteHosts []string, creds []HostCredentials) http.RoundTripper {
+	if len(creds) == 0 {
+		return base
+	}
+	scheme := "https"
+	site := siteHosts
+	if u, err := url.Parse(entrypoint); err == nil {
+		scheme = u.Scheme
+		site = append([]string{u.Host}, siteHosts...)
+	}
+	t := &authTransport{base: base}
+	for _, c := range creds {
+		hosts := site
+		if len(c.Hosts) > 0 {
+			hosts = c.Hosts
+		}
+		origins := make(map[string]bool)
+		for _, h := range hosts {
+			h = strings.TrimSpace(h)
+			if !strings.Contains(h, "://") {
+				h = scheme + "://" + h
+			}
+			if u, err := url.Parse(h); err == nil && u.Host != "" {
+				origins[requestOrigin(u)] = true
+			}
+		}
+		t.rules = append(t.rules, hostCredentials{origins: origins, creds: c})
+	}
+	return t
+}
+
+// requestOrigin returns the scheme, host and port u is requested from, as
+// scheme://host:port with the scheme's default port filled in.
+func requestOrigin(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if scheme == "https" {
+			port = "443"
+		}
+	}
+	return scheme + "://" + net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cloned := false
+	origin := requestOrigin(req.URL)
+	for _, rule := range t.rules {
+		if !rule.origins[origin] {
+			continue
+		}
+		// A RoundTripper must not modify the request it was given
+		if !cloned {
+			req = req.Clone(req.Context())
+			cloned = true
+		}
+		for name, values := range rule.creds.Header {
+			req.Header[http.CanonicalHeaderKey(name)] = values
+		}
+		if rule.creds.Username != "" {
+			req.SetBasicAuth(rule.creds.Username, rule.creds.Password)
+		}
+		if rule.creds.BearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+rule.creds.BearerToken)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// CloseIdleConnections closes idle connections of the underlying transport.
+func (t *authTransport) CloseIdleConnections() {
+	closeIdleConnections(t.base)
+}
+
+// LoadCookieFile reads a cookies.txt file, see LoadCookies.
+func LoadCookieFile(filename string) (*cookiejar.Jar, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	jar, err := LoadCookies(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return jar, nil
+}
+
+// LoadCookies returns a cookie jar holding the cookies of a Netscape
+// cookies.txt file, as exported by browsers and written by curl. Cookies are
+// only sent to the domains they were set for; expired cookies are skipped.
+func LoadCookies(r io.Reader) (*cookiejar.Jar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		// curl marks HttpOnly cookies with a prefix on otherwise normal lines
+		httpOnly := strings.HasPrefix(text, "#HttpOnly_")
+		text = strings.TrimPrefix(text, "#HttpOnly_")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: got %d tab separated fields, want 7", line, len(fields))
+		}
+		domain, subdomains, path, secure, expires, name, value := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+
+		cookie := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     path,
+			Secure:   strings.EqualFold(secure, "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if seconds, err := strconv.ParseInt(expires, 10, 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q", line, expires)
+		} else if seconds > 0 {
+			if cookie.Expires = time.Unix(seconds, 0); cookie.Expires.Before(now) {
+				continue
+			}
+		}
+
+		host := strings.TrimPrefix(domain, ".")
+		if strings.EqualFold(subdomains, "TRUE") {
+			cookie.Domain = host
+		}
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: path}, []*http.Cookie{cookie})
+	}
+	return jar, scanner.Err()
+}
@@ -0,0 +1,205 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newHeaderServer returns a server that records the request headers it got
+// for each path. handler, if not nil, answers the requests.
+func newHeaderServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, func(path string) http.Header) {
+	t.Helper()
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]http.Header)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path] = r.Header.Clone()
+		mu.Unlock()
+		if handler != nil {
+			handler(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func(path string) http.Header {
+		mu.Lock()
+		defer mu.Unlock()
+		return seen[path]
+	}
+}
+
+// ---- Credentials --------------------------------------------------------
+
+func TestCheckURLStatus_CredentialsScopedToHosts(t *testing.T) {
+	t.Parallel()
+	other, otherHeaders := newHeaderServer(t, nil)
+	site, siteHeaders := newHeaderServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/away" {
+			http.Redirect(w, r, other.URL+"/landing", http.StatusFound)
+		}
+	})
+
+	c, err := New(site.URL+"/sitemap.xml", Options{
+		Timeout: 5 * time.Second,
+		Credentials: []HostCredentials{{
+			Header:   http.Header{"X-Env": {"staging"}},
+			Username: "bot",
+			Password: "secret",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	links := []Link{{URL: site.URL + "/page"}, {URL: site.URL + "/away"}, {URL: other.URL + "/direct"}}
+	checkURLStatus(context.Background(), c, links)
+
+	for _, path := range []string{"/page", "/away"} {
+		h := siteHeaders(path)
+		if h.Get("X-Env") != "staging" {
+			t.Errorf("%s: X-Env = %q, want staging", path, h.Get("X-Env"))
+		}
+		if !strings.HasPrefix(h.Get("Authorization"), "Basic ") {
+			t.Errorf("%s: Authorization = %q, want basic auth", path, h.Get("Authorization"))
+		}
+	}
+	// Neither links to nor redirects to other hosts carry the credentials
+	for _, path := range []string{"/landing", "/direct"} {
+		h := otherHeaders(path)
+		if h == nil {
+			t.Fatalf("%s was not requested", path)
+		}
+		if h.Get("X-Env") != "" || h.Get("Authorization") != "" {
+			t.Errorf("%s: got credentials %v sent to a third-party host", path, h)
+		}
+	}
+}
+
+func TestCheckURLStatus_BearerTokenForListedHosts(t *testing.T) {
+	t.Parallel()
+	api, apiHeaders := newHeaderServer(t, nil)
+	site, siteHeaders := newHeaderServer(t, nil)
+	apiHost := strings.TrimPrefix(api.URL, "http://")
+
+	c, err := New(site.URL+"/sitemap.xml", Options{
+		Timeout:     5 * time.Second,
+		Credentials: []HostCredentials{{Hosts: []string{apiHost}, BearerToken: "t0ken"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	checkURLStatus(context.Background(), c, []Link{{URL: site.URL + "/page"}, {URL: api.URL + "/v1"}})
+
+	if got := apiHeaders("/v1").Get("Authorization"); got != "Bearer t0ken" {
+		t.Errorf("listed host: Authorization = %q, want Bearer t0ken", got)
+	}
+	if got := siteHeaders("/page").Get("Authorization"); got != "" {
+		t.Errorf("site host: Authorization = %q, want none", got)
+	}
+}
+
+// roundTripFunc is an http.RoundTripper calling itself.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestAuthTransport_SchemeAndPort(t *testing.T) {
+	t.Parallel()
+	var got *http.Request
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	transport := newAuthTransport(base, "https://example.com/sitemap.xml", []string{"www.example.com"}, []HostCredentials{
+		{Username: "bot", Password: "secret"},
+		{Hosts: []string{"api.example.com:8443", "http://legacy.example.com"}, BearerToken: "t0ken"},
+	})
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/page", "Basic"},
+		{"https://EXAMPLE.com:443/page", "Basic"},
+		{"https://www.example.com/page", "Basic"},
+		{"http://example.com/page", ""},
+		{"https://example.com:8443/page", ""},
+		{"https://api.example.com:8443/v1", "Bearer"},
+		{"https://api.example.com/v1", ""},
+		{"http://legacy.example.com/v1", "Bearer"},
+		{"https://legacy.example.com/v1", ""},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip(%s) error = %v", tt.url, err)
+		}
+		scheme, _, _ := strings.Cut(got.Header.Get("Authorization"), " ")
+		if scheme != tt.want {
+			t.Errorf("%s: Authorization scheme = %q, want %q", tt.url, scheme, tt.want)
+		}
+	}
+}
+
+// ---- LoadCookies --------------------------------------------------------
+
+const testCookies = "# Netscape HTTP Cookie File\n" +
+	"\n" +
+	"example.com\tFALSE\t/\tFALSE\t0\tsession\tabc\n" +
+	".example.org\tTRUE\t/\tFALSE\t0\tlang\tsv\n" +
+	"#HttpOnly_example.com\tFALSE\t/admin\tTRUE\t4102444800\tadmin\tyes\n" +
+	"example.com\tFALSE\t/\tFALSE\t1\texpired\tgone\n"
+
+func cookieNames(jar http.CookieJar, rawURL string) []string {
+	u, _ := url.Parse(rawURL)
+	var names []string
+	for _, c := range jar.Cookies(u) {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestLoadCookies(t *testing.T) {
+	t.Parallel()
+	jar, err := LoadCookies(strings.NewReader(testCookies))
+	if err != nil {
+		t.Fatalf("LoadCookies() error = %v", err)
+	}
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"http://example.com/", "session"},
+		{"https://example.com/admin/users", "admin session"},
+		{"http://example.com/admin/users", "session"},
+		{"http://www.example.com/", ""},
+		{"http://example.org/", "lang"},
+		{"http://blog.example.org/", "lang"},
+		{"http://other.com/", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(cookieNames(jar, tt.url), " "); got != tt.want {
+			t.Errorf("cookies for %s = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCookies_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, data := range []string{
+		"example.com\tFALSE\t/\tFALSE\t0\tsession\n",
+		"example.com\tFALSE\t/\tFALSE\tsoon\tsession\tabc\n",
+	} {
+		if _, err := LoadCookies(strings.NewReader(data)); err == nil {
+			t.Errorf("LoadCookies(%q): expected an error", data)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// baselineDate is the layout of BaselineEntry.Expires.
+const baselineDate = "2006-01-02"
+
+// Baseline lists known and accepted problems, such as links to sites that
+// block bots, that are left out of the report.
+type Baseline struct {
+	Entries []BaselineEntry `yaml:"ignore"`
+}
+
+// BaselineEntry accepts the problems of a link target, either wherever it is
+// linked from or only on a single page.
+type BaselineEntry struct {
+	// URL is the link target: an exact URL or a pattern as in URLFilter.
+	// Missing anchors are matched as the URL followed by #fragment.
+	URL string `yaml:"url"`
+	// Page, if set, limits the entry to links found on this exact page.
+	Page string `yaml:"page,omitempty"`
+	// Reason documents why the problem is accepted.
+	Reason string `yaml:"reason,omitempty"`
+	// Expires is the last day, as YYYY-MM-DD, the entry applies. After that
+	// the problem is reported again. Entries without one never expire.
+	Expires string `yaml:"expires,omitempty"`
+
+	pattern *regexp.Regexp
+	// end is the moment the entry expires, zero if never.
+	end time.Time
+}
+
+// LoadBaseline reads a baseline file, see ParseBaseline.
+func LoadBaseline(filename string) (*Baseline, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ParseBaseline(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return b, nil
+}
+
+// ParseBaseline parses a YAML baseline file, e.g.
+//
+//	ignore:
+//	  - url: https://twitter.com/*
+//	    reason: blocks bots
+//	    expires: 2025-12-31
+//	  - url: https://example.com/old-page
+//	    page: https://example.com/blog/post
+func ParseBaseline(data []byte) (*Baseline, error) {
+	var b Baseline
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	for i := range b.Entries {
+		e := &b.Entries[i]
+		if e.URL == "" {
+			return nil, fmt.Errorf("baseline entry %d has no url", i+1)
+		}
+		var err error
+		if e.pattern, err = compilePattern(e.URL); err != nil {
+			return nil, fmt.Errorf("invalid URL pattern %q: %w", e.URL, err)
+		}
+		if e.Expires != "" {
+			day, err := time.Parse(baselineDate, e.Expires)
+			if err != nil {
+				return nil, fmt.Errorf("baseline entry for %s: invalid expiry date %q, want YYYY-MM-DD", e.URL, e.Expires)
+			}
+			e.end = day.AddDate(0, 0, 1)
+		}
+	}
+	return &b, nil
+}
+
+// NewBaseline returns a baseline accepting every broken link, request error
+// and missing anchor in results, with one entry for each page it was found
+// on. Entries expire at the end of the day of expires, or never if it is
+// the zero time.
+func NewBaseline(results []LinkResult, expires time.Time) *Baseline {
+	var date string
+	if !expires.IsZero() {
+		date = expires.Format(baselineDate)
+	}
+	b := &Baseline{}
+	for _, result := range results {
+		target, reason := result.URL, result.Error
+		switch result.Category {
+		case CategoryBroken:
+			reason = fmt.Sprintf("HTTP %d %s", result.StatusCode, http.StatusText(result.StatusCode))
+		case CategoryMissingAnchor:
+			target += "#" + result.Fragment
+		case CategoryRequestError, CategoryRedirectLoop:
+		default:
+			continue
+		}
+		for _, o := range result.Origins {
+			b.Entries = append(b.Entries, BaselineEntry{URL: target, Page: o.URL, Reason: reason, Expires: date})
+		}
+	}
+	return b
+}
+
+// Write writes the baseline to w as YAML.
+func (b *Baseline) Write(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(b); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Expired returns the entries that have expired by now.
+func (b *Baseline) Expired(now time.Time) []BaselineEntry {
+	var expired []BaselineEntry
+	for _, e := range b.Entries {
+		if e.expired(now) {
+			expired = append(expired, e)
+		}
+	}
+	return expired
+}
+
+func (e BaselineEntry) expired(now time.Time) bool {
+	return !e.end.IsZero() && !now.Before(e.end)
+}
+
+// suppresses reports whether an entry accepts the link to target found on
+// page.
+func (b *Baseline) suppresses(target, page string, now time.Time) bool {
+	for _, e := range b.Entries {
+		if (e.Page == "" || e.Page == page) && !e.expired(now) && e.pattern.MatchString(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitOrigins splits the origins of a link to target into those still
+// reported and those the baseline accepts.
+func (b *Baseline) splitOrigins(target string, origins []Origin, now time.Time) (kept, suppressed []Origin) {
+	for _, o := range origins {
+		if b.suppresses(target, o.URL, now) {
+			suppressed = append(suppressed, o)
+		} else {
+			kept = append(kept, o)
+		}
+	}
+	return kept, suppressed
+}
+
+// filterResult applies the baseline to a link result about to be reported.
+// It returns false if every origin of a problem is accepted.
+func (b *Baseline) filterResult(result *LinkResult, now time.Time) bool {
+	target := result.URL
+	switch result.Category {
+	case CategoryMissingAnchor:
+		target += "#" + result.Fragment
+	case CategoryBroken, CategoryRequestError, CategoryRedirectLoop:
+	default:
+		return true
+	}
+	kept, _ := b.splitOrigins(target, result.Origins, now)
+	result.Origins = kept
+	return len(kept) > 0
+}
+
+// applyBaseline moves every problem in the report that b accepts to
+// report.Suppressed. Links accepted on only some of their pages stay in the
+// report with the remaining pages.
+func (r *Report) applyBaseline(b *Baseline, now time.Time) {
+	var broken []CrawlResponse
+	for _, item := range r.Broken {
+		kept, suppressed := b.splitOrigins(item.URL, item.Origins, now)
+		if len(suppressed) > 0 {
+			accepted := item
+			accepted.Origins = suppressed
+			r.Suppressed = append(r.Suppressed, responseResult(accepted))
+		}
+		if item.Origins = kept; len(kept) > 0 {
+			broken = append(broken, item)
+		}
+	}
+	r.Broken = broken
+
+	var requestErrors []RequestError
+	for _, e := range r.RequestErrors {
+		kept, suppressed := b.splitOrigins(e.URL, e.Origins, now)
+		if len(suppressed) > 0 {
+			accepted := e
+			accepted.Origins = suppressed
+			r.Suppressed = append(r.Suppressed, requestErrorResult(accepted))
+		}
+		if e.Origins = kept; len(kept) > 0 {
+			requestErrors = append(requestErrors, e)
+		}
+	}
+	r.RequestErrors = requestErrors
+
+	var anchors []MissingAnchor
+	for _, m := range r.MissingAnchors {
+		kept, suppressed := b.splitOrigins(m.URL+"#"+m.Fragment, m.Origins, now)
+		if len(suppressed) > 0 {
+			accepted := m
+			accepted.Origins = suppressed
+			r.Suppressed = append(r.Suppressed, missingAnchorResult(accepted))
+		}
+		if m.Origins = kept; len(kept) > 0 {
+			anchors = append(anchors, m)
+		}
+	}
+	r.MissingAnchors = anchors
+}
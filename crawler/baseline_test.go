@@ -0,0 +1,177 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBaseline = `
+ignore:
+  - url: https://twitter.com/*
+    reason: blocks bots
+  - url: https://example.com/broken
+    page: https://example.com/a
+    expires: 2024-06-30
+  - url: https://example.com/docs#install
+  - url: https://example.com/gone
+    expires: 2024-01-31
+`
+
+func mustParseBaseline(t *testing.T, data string) *Baseline {
+	t.Helper()
+	b, err := ParseBaseline([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseBaseline() error = %v", err)
+	}
+	return b
+}
+
+// ---- ParseBaseline ------------------------------------------------------
+
+func TestParseBaseline_Errors(t *testing.T) {
+	t.Parallel()
+	for _, data := range []string{
+		"ignore:\n  - page: https://example.com/\n",
+		"ignore:\n  - url: https://example.com/\n    expires: 31/12/2024\n",
+		"ignore:\n  - url: 're:('\n",
+		"ignore: [",
+	} {
+		if _, err := ParseBaseline([]byte(data)); err == nil {
+			t.Errorf("ParseBaseline(%q): expected an error", data)
+		}
+	}
+}
+
+func TestBaselineSuppresses(t *testing.T) {
+	t.Parallel()
+	b := mustParseBaseline(t, testBaseline)
+	june := time.Date(2024, 6, 30, 23, 0, 0, 0, time.UTC)
+	july := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		target, page string
+		now          time.Time
+		want         bool
+	}{
+		{"https://twitter.com/someone", "https://example.com/a", july, true},
+		{"https://example.com/broken", "https://example.com/a", june, true},
+		{"https://example.com/broken", "https://example.com/b", june, false},
+		{"https://example.com/broken", "https://example.com/a", july, false},
+		{"https://example.com/docs#install", "https://example.com/b", july, true},
+		{"https://example.com/docs", "https://example.com/b", july, false},
+	}
+	for _, tt := range tests {
+		if got := b.suppresses(tt.target, tt.page, tt.now); got != tt.want {
+			t.Errorf("suppresses(%q, %q, %s) = %v, want %v", tt.target, tt.page, tt.now.Format(time.DateTime), got, tt.want)
+		}
+	}
+
+	expired := b.Expired(july)
+	if len(expired) != 2 || expired[0].URL != "https://example.com/broken" || expired[1].URL != "https://example.com/gone" {
+		t.Errorf("Expired() = %+v, want the two dated entries", expired)
+	}
+}
+
+// ---- applyBaseline ------------------------------------------------------
+
+func TestReportApplyBaseline(t *testing.T) {
+	t.Parallel()
+	b := mustParseBaseline(t, testBaseline)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	a := Origin{URL: "https://example.com/a"}
+	other := Origin{URL: "https://example.com/b"}
+	broken := CrawlResponse{URL: "https://example.com/broken", StatusCode: 404, Origins: []Origin{a, other}}
+	blocked := CrawlResponse{URL: "https://twitter.com/someone", StatusCode: 403, Origins: []Origin{a}}
+	report := &Report{
+		OK:             1,
+		Broken:         []CrawlResponse{broken, blocked},
+		RequestErrors:  []RequestError{{URL: "https://twitter.com/x", Err: ErrRedirectLoop, Origins: []Origin{other}}},
+		MissingAnchors: []MissingAnchor{{URL: "https://example.com/docs", Fragment: "install", Origins: []Origin{a}}},
+	}
+	report.applyBaseline(b, now)
+
+	if len(report.Broken) != 1 || report.Broken[0].URL != broken.URL || len(report.Broken[0].Origins) != 1 || report.Broken[0].Origins[0] != other {
+		t.Errorf("Broken = %+v, want only the /broken link on the other page", report.Broken)
+	}
+	if len(report.RequestErrors) != 0 || len(report.MissingAnchors) != 0 {
+		t.Errorf("expected accepted errors and anchors to be removed, got %v and %v", report.RequestErrors, report.MissingAnchors)
+	}
+	if len(report.Suppressed) != 4 {
+		t.Errorf("got %d suppressed results, want 4: %+v", len(report.Suppressed), report.Suppressed)
+	}
+	if s := report.Summary(); s.Broken != 1 || s.OK != 1 || s.Suppressed != 4 {
+		t.Errorf("Summary() = %+v, want 1 broken, 1 OK and 4 suppressed", s)
+	}
+}
+
+// ---- NewBaseline --------------------------------------------------------
+
+func TestNewBaselineRoundTrip(t *testing.T) {
+	t.Parallel()
+	report := sampleReport()
+	for i := range report.Broken {
+		report.Broken[i].Origins = []Origin{{URL: "https://example.com/"}}
+	}
+	for i := range report.RequestErrors {
+		report.RequestErrors[i].Origins = []Origin{{URL: "https://example.com/"}}
+	}
+	report.MissingAnchors[0].Origins = []Origin{{URL: "https://example.com/guide"}}
+
+	b := NewBaseline(report.LinkResults(), time.Date(2024, 9, 1, 15, 0, 0, 0, time.UTC))
+	if len(b.Entries) != 4 {
+		t.Fatalf("got %d entries, want 4: %+v", len(b.Entries), b.Entries)
+	}
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, want := range []string{"url: https://example.com/broken", "reason: HTTP 404 Not Found", "expires: \"2024-09-01\"", "url: https://example.com/docs#install"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("baseline does not contain %q:\n%s", want, buf.String())
+		}
+	}
+
+	parsed := mustParseBaseline(t, buf.String())
+	report.applyBaseline(parsed, time.Date(2024, 9, 1, 23, 59, 0, 0, time.UTC))
+	if s := report.Summary(); s.Broken != 0 || s.RequestErrors != 0 || s.MissingAnchors != 0 {
+		t.Errorf("Summary() = %+v, want every problem accepted", s)
+	}
+}
+
+// ---- Run with a baseline ------------------------------------------------
+
+func TestRun_Baseline(t *testing.T) {
+	t.Parallel()
+	srv := newSiteServer(t)
+	b := mustParseBaseline(t, "ignore:\n  - url: "+srv.URL+"/missing\n    page: "+srv.URL+"/a\n")
+
+	var streamed []LinkResult
+	c, err := New(srv.URL+"/sitemap.xml", Options{
+		Timeout:  5 * time.Second,
+		Baseline: b,
+		OnResult: func(r LinkResult) { streamed = append(streamed, r) },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(report.Broken) != 1 || len(report.Broken[0].Origins) != 1 || report.Broken[0].Origins[0].URL != srv.URL+"/b" {
+		t.Errorf("Broken = %+v, want /missing only on /b", report.Broken)
+	}
+	if len(report.Suppressed) != 1 || report.Suppressed[0].Origins[0].URL != srv.URL+"/a" {
+		t.Errorf("Suppressed = %+v, want /missing on /a", report.Suppressed)
+	}
+	for _, r := range streamed {
+		if r.URL == srv.URL+"/missing" && len(r.Origins) != 1 {
+			t.Errorf("streamed %+v, want the accepted page left out", r)
+		}
+	}
+}
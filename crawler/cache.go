@@ -0,0 +1,250 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultCacheTTL is how long link results are reused by default: working
+// links for a week and broken ones for a day. Server errors are always
+// checked again, as they are often temporary.
+var DefaultCacheTTL = CacheTTL{
+	OK:          7 * 24 * time.Hour,
+	Redirect:    24 * time.Hour,
+	ClientError: 24 * time.Hour,
+}
+
+// CacheTTL sets how long a link result is reused, per status class. A zero
+// TTL means results of that class are never reused.
+type CacheTTL struct {
+	// OK applies to 2xx responses.
+	OK time.Duration
+	// Redirect applies to links ending in a 3xx response, e.g. a redirect
+	// without a Location.
+	Redirect time.Duration
+	// ClientError applies to 4xx responses.
+	ClientError time.Duration
+	// ServerError applies to 5xx and any other status.
+	ServerError time.Duration
+}
+
+// ParseCacheTTL parses a comma separated list of class=duration pairs such
+// as "2xx=72h,4xx=1h", where the class is 2xx, 3xx, 4xx or 5xx. Classes not
+// listed keep their DefaultCacheTTL.
+func ParseCacheTTL(s string) (CacheTTL, error) {
+	ttl := DefaultCacheTTL
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		class, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return CacheTTL{}, fmt.Errorf("invalid cache TTL %q, want class=duration", part)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d < 0 {
+			return CacheTTL{}, fmt.Errorf("invalid cache TTL duration %q", value)
+		}
+		switch strings.ToLower(strings.TrimSpace(class)) {
+		case "2xx":
+			ttl.OK = d
+		case "3xx":
+			ttl.Redirect = d
+		case "4xx":
+			ttl.ClientError = d
+		case "5xx":
+			ttl.ServerError = d
+		default:
+			return CacheTTL{}, fmt.Errorf("unknown status class %q, want 2xx, 3xx, 4xx or 5xx", class)
+		}
+	}
+	return ttl, nil
+}
+
+// forStatus returns the TTL of results with status code.
+func (t CacheTTL) forStatus(code int) time.Duration {
+	switch {
+	case code >= 200 && code <= 299 || code == 999:
+		return t.OK
+	case code >= 300 && code <= 399:
+		return t.Redirect
+	case code >= 400 && code <= 499:
+		return t.ClientError
+	}
+	return t.ServerError
+}
+
+var (
+	linksBucket = []byte("links")
+	pagesBucket = []byte("pages")
+)
+
+// Cache keeps link results and page validators across runs in a file, so
+// recently verified third-party links are not checked again and unchanged
+// pages are not scraped again. Links into the site itself are always
+// checked, as they change with every deploy.
+type Cache struct {
+	// TTL sets how long link results are reused. OpenCache sets it to
+	// DefaultCacheTTL.
+	TTL CacheTTL
+
+	db *bolt.DB
+}
+
+// cachedLink is the stored outcome of checking a link.
+type cachedLink struct {
+	StatusCode int        `json:"status_code"`
+	Redirects  []Redirect `json:"redirects,omitempty"`
+	FinalURL   string     `json:"final_url,omitempty"`
+	Expires    time.Time  `json:"expires"`
+}
+
+// cachedPage holds the validators of a scraped page and the links of Kinds
+// found on it, to reuse when the page answers 304 Not Modified.
+type cachedPage struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Kinds        []Kind `json:"kinds"`
+	Links        []Link `json:"links"`
+}
+
+// OpenCache opens the cache file, creating it if needed. Only one process
+// can use a cache file at a time.
+func OpenCache(filename string) (*Cache, error) {
+	db, err := bolt.Open(filename, 0o644, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("cache %s is in use by another crawl", filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache %s: %w", filename, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{linksBucket, pagesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cache %s: %w", filename, err)
+	}
+	return &Cache{TTL: DefaultCacheTTL, db: db}, nil
+}
+
+// Close closes the cache file.
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+func (c *Cache) get(bucket []byte, key string, v any) bool {
+	var found bool
+	c.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(key))
+		found = data != nil && json.Unmarshal(data, v) == nil
+		return nil
+	})
+	return found
+}
+
+// put stores v under key. Writes from concurrent checks are batched into a
+// single transaction.
+func (c *Cache) put(bucket []byte, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), data)
+	})
+}
+
+// link returns the cached result of checking rawURL, if it has not expired.
+func (c *Cache) link(rawURL string, now time.Time) (cachedLink, bool) {
+	var entry cachedLink
+	if !c.get(linksBucket, rawURL, &entry) || !now.Before(entry.Expires) {
+		return cachedLink{}, false
+	}
+	return entry, true
+}
+
+// storeLink caches the result of a check for the TTL of its status class.
+// A 429 or 503 that persisted through every retry is never cached, as it
+// says nothing about the link once the server is available again.
+func (c *Cache) storeLink(result CrawlResponse, now time.Time) error {
+	ttl := c.TTL.forStatus(result.StatusCode)
+	if ttl <= 0 || isRetryableStatus(result.StatusCode) {
+		return nil
+	}
+	return c.put(linksBucket, result.URL, cachedLink{
+		StatusCode: result.StatusCode,
+		Redirects:  result.Redirects,
+		FinalURL:   result.FinalURL,
+		Expires:    now.Add(ttl),
+	})
+}
+
+// page returns the validators and links stored for a page, unless they were
+// collected for other kinds of links.
+func (c *Cache) page(rawURL string, kinds []Kind) (cachedPage, bool) {
+	var entry cachedPage
+	if !c.get(pagesBucket, rawURL, &entry) || !slices.Equal(entry.Kinds, kinds) {
+		return cachedPage{}, false
+	}
+	return entry, true
+}
+
+// storePage caches the links of a page if its response carries validators
+// that allow a conditional request next time.
+func (c *Cache) storePage(rawURL string, header http.Header, kinds []Kind, links []Link) error {
+	entry := cachedPage{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Kinds:        kinds,
+		Links:        links,
+	}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return nil
+	}
+	return c.put(pagesBucket, rawURL, entry)
+}
+
+// cachedResult returns the cached result of an external link, if any.
+func (c *Crawler) cachedResult(input Link) (CrawlResponse, bool) {
+	if c.opts.Cache == nil || isAllowedHost(input.URL, c.hosts) {
+		return CrawlResponse{}, false
+	}
+	entry, ok := c.opts.Cache.link(input.URL, time.Now())
+	if !ok {
+		return CrawlResponse{}, false
+	}
+	return CrawlResponse{
+		URL:        input.URL,
+		Origins:    input.Origins,
+		StatusCode: entry.StatusCode,
+		Redirects:  entry.Redirects,
+		FinalURL:   entry.FinalURL,
+		// The redirect policy may have changed since
+		Warnings: c.opts.Redirects.redirectWarnings(entry.Redirects, entry.FinalURL),
+		Cached:   true,
+	}, true
+}
+
+// cacheResult stores the result of checking an external link.
+func (c *Crawler) cacheResult(result CrawlResponse) {
+	if c.opts.Cache == nil || isAllowedHost(result.URL, c.hosts) {
+		return
+	}
+	if err := c.opts.Cache.storeLink(result, time.Now()); err != nil {
+		c.logf("Failed to cache result for %s: %v\n", result.URL, err)
+	}
+}
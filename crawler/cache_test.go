@@ -0,0 +1,222 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// openTestCache opens a cache in a temporary directory, closed when the test
+// ends.
+func openTestCache(t *testing.T) *Cache {
+	t.Helper()
+	cache, err := OpenCache(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("OpenCache() error = %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+// newCountingServer answers /ok with 200, /gone with 404 and /down with 503,
+// counting the requests it gets.
+func newCountingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var count atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		switch r.URL.Path {
+		case "/ok":
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &count
+}
+
+// ---- ParseCacheTTL ------------------------------------------------------
+
+func TestParseCacheTTL(t *testing.T) {
+	t.Parallel()
+	got, err := ParseCacheTTL("2xx=72h, 5XX=10m")
+	if err != nil {
+		t.Fatalf("ParseCacheTTL() error = %v", err)
+	}
+	want := DefaultCacheTTL
+	want.OK, want.ServerError = 72*time.Hour, 10*time.Minute
+	if got != want {
+		t.Errorf("ParseCacheTTL() = %+v, want %+v", got, want)
+	}
+
+	if got, _ := ParseCacheTTL(""); got != DefaultCacheTTL {
+		t.Errorf("ParseCacheTTL(\"\") = %+v, want the defaults", got)
+	}
+	for _, s := range []string{"2xx", "1xx=1h", "4xx=soon", "4xx=-1h"} {
+		if _, err := ParseCacheTTL(s); err == nil {
+			t.Errorf("ParseCacheTTL(%q): expected an error", s)
+		}
+	}
+}
+
+func TestCacheTTLForStatus(t *testing.T) {
+	t.Parallel()
+	ttl := CacheTTL{OK: 1, Redirect: 2, ClientError: 3, ServerError: 4}
+	for code, want := range map[int]time.Duration{200: 1, 204: 1, 999: 1, 304: 2, 404: 3, 500: 4, 600: 4} {
+		if got := ttl.forStatus(code); got != want {
+			t.Errorf("forStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+// ---- link results -------------------------------------------------------
+
+func TestCheckURLStatus_ReusesCachedExternalResults(t *testing.T) {
+	t.Parallel()
+	site, siteCount := newCountingServer(t)
+	other, otherCount := newCountingServer(t)
+	cache := openTestCache(t)
+
+	c, err := New(site.URL+"/sitemap.xml", Options{
+		Timeout: 5 * time.Second,
+		Retry:   RetryPolicy{Attempts: 1},
+		Cache:   cache,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	links := []Link{
+		{URL: site.URL + "/ok"},
+		{URL: other.URL + "/ok"},
+		{URL: other.URL + "/gone"},
+		{URL: other.URL + "/down"},
+	}
+
+	checkURLStatus(context.Background(), c, links)
+	if site, other := siteCount.Load(), otherCount.Load(); site != 1 || other != 3 {
+		t.Fatalf("first run: got %d site and %d external requests, want 1 and 3", site, other)
+	}
+
+	// Only the site's own link and the server error are checked again
+	crawled, broken, _ := checkURLStatus(context.Background(), c, links)
+	if site, other := siteCount.Load(), otherCount.Load(); site != 2 || other != 4 {
+		t.Errorf("second run: got %d site and %d external requests in total, want 2 and 4", site, other)
+	}
+	if len(crawled) != 4 || len(broken) != 2 {
+		t.Fatalf("second run: got %d results and %d broken, want 4 and 2", len(crawled), len(broken))
+	}
+	cached := make(map[string]bool)
+	for _, r := range crawled {
+		cached[r.URL] = r.Cached
+	}
+	want := map[string]bool{site.URL + "/ok": false, other.URL + "/ok": true, other.URL + "/gone": true, other.URL + "/down": false}
+	for u, w := range want {
+		if cached[u] != w {
+			t.Errorf("%s: Cached = %v, want %v", u, cached[u], w)
+		}
+	}
+}
+
+func TestCacheLink_Expires(t *testing.T) {
+	t.Parallel()
+	cache := openTestCache(t)
+	cache.TTL = CacheTTL{OK: time.Hour}
+	now := time.Now()
+
+	if err := cache.storeLink(CrawlResponse{URL: "https://example.com/", StatusCode: 200}, now); err != nil {
+		t.Fatalf("storeLink() error = %v", err)
+	}
+	if err := cache.storeLink(CrawlResponse{URL: "https://example.com/gone", StatusCode: 404}, now); err != nil {
+		t.Fatalf("storeLink() error = %v", err)
+	}
+	if _, ok := cache.link("https://example.com/", now.Add(59*time.Minute)); !ok {
+		t.Error("result missing before its TTL passed")
+	}
+	if _, ok := cache.link("https://example.com/", now.Add(time.Hour)); ok {
+		t.Error("result reused after its TTL passed")
+	}
+	if _, ok := cache.link("https://example.com/gone", now); ok {
+		t.Error("result of a class without TTL was cached")
+	}
+}
+
+func TestCacheLink_SkipsRetryableStatus(t *testing.T) {
+	t.Parallel()
+	cache := openTestCache(t)
+	now := time.Now()
+
+	for _, code := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		u := "https://example.com/" + strconv.Itoa(code)
+		if err := cache.storeLink(CrawlResponse{URL: u, StatusCode: code}, now); err != nil {
+			t.Fatalf("storeLink() error = %v", err)
+		}
+		if _, ok := cache.link(u, now); ok {
+			t.Errorf("result with status %d was cached", code)
+		}
+	}
+}
+
+func TestOpenCache_InUse(t *testing.T) {
+	t.Parallel()
+	filename := filepath.Join(t.TempDir(), "cache.db")
+	cache, err := OpenCache(filename)
+	if err != nil {
+		t.Fatalf("OpenCache() error = %v", err)
+	}
+	defer cache.Close()
+	if _, err := OpenCache(filename); err == nil {
+		t.Error("OpenCache() of a file in use: expected an error")
+	}
+}
+
+// ---- pages --------------------------------------------------------------
+
+func TestGetPageLinks_ConditionalRequest(t *testing.T) {
+	t.Parallel()
+	var fetched, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetched.Add(1)
+		fmt.Fprint(w, `<html><body><a href="/a">A</a><a href="https://example.com/">B</a></body></html>`)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL+"/sitemap.xml", Options{Timeout: 5 * time.Second, Cache: openTestCache(t)})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	first := c.getPageLinks(context.Background(), srv.URL+"/page")
+	second := c.getPageLinks(context.Background(), srv.URL+"/page")
+
+	if fetched.Load() != 1 || notModified.Load() != 1 {
+		t.Errorf("got %d full and %d not modified responses, want 1 and 1", fetched.Load(), notModified.Load())
+	}
+	if len(first) != 2 || len(second) != len(first) {
+		t.Fatalf("got %d and %d links, want 2 both times", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].URL != second[i].URL || first[i].Origins[0] != second[i].Origins[0] {
+			t.Errorf("link %d = %+v from the cache, want %+v", i, second[i], first[i])
+		}
+	}
+
+	// Links cached for other kinds are not reused
+	c.opts.Kinds = []Kind{KindAnchor, KindImage}
+	c.getPageLinks(context.Background(), srv.URL+"/page")
+	if fetched.Load() != 2 {
+		t.Errorf("page with links of other kinds cached: got %d full responses, want 2", fetched.Load())
+	}
+}
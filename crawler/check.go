@@ -0,0 +1,482 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrRedirectLoop is reported for links whose redirects lead back to a URL
+// already visited.
+var ErrRedirectLoop = errors.New("redirect loop")
+
+// Redirect is a single hop in a redirect chain.
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+// RedirectPolicy configures which redirects are reported as warnings.
+// Redirect loops are always reported as request errors.
+type RedirectPolicy struct {
+	// WarnPermanent warns about links answered with a permanent redirect
+	// (301 or 308), since the link should be updated to the new location.
+	WarnPermanent bool
+	// WarnDowngrade warns about redirects from HTTPS to plain HTTP.
+	WarnDowngrade bool
+}
+
+// redirectChainKey is the context key under which the redirect chain of a
+// request is recorded.
+type redirectChainKey struct{}
+
+func redirectTrim(req *http.Request, via []*http.Request) error {
+	if len(via) >= 25 {
+		return errors.New("stopped after 25 redirects")
+	}
+	return nil
+}
+
+// checkRedirect is the CheckRedirect hook of the crawler's client. It
+// records every hop in the chain attached to the request context, if any,
+// and stops on redirect loops and after too many redirects.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	prev := via[len(via)-1]
+	if chain, ok := req.Context().Value(redirectChainKey{}).(*[]Redirect); ok && req.Response != nil {
+		*chain = append(*chain, Redirect{
+			URL:        prev.URL.String(),
+			StatusCode: req.Response.StatusCode,
+			Location:   req.URL.String(),
+		})
+	}
+	for _, r := range via {
+		if r.URL.String() == req.URL.String() {
+			return ErrRedirectLoop
+		}
+	}
+	return redirectTrim(req, via)
+}
+
+// redirectWarnings returns the warnings the policy raises for a chain of
+// redirects ending at finalURL.
+func (p RedirectPolicy) redirectWarnings(hops []Redirect, finalURL string) []string {
+	var warnings []string
+	if p.WarnPermanent {
+		for _, hop := range hops {
+			if hop.StatusCode == http.StatusMovedPermanently || hop.StatusCode == http.StatusPermanentRedirect {
+				warnings = append(warnings, "Permanent redirect, update your link to "+finalURL)
+				break
+			}
+		}
+	}
+	if p.WarnDowngrade {
+		for _, hop := range hops {
+			from, err1 := url.Parse(hop.URL)
+			to, err2 := url.Parse(hop.Location)
+			if err1 == nil && err2 == nil && from.Scheme == "https" && to.Scheme == "http" {
+				warnings = append(warnings, fmt.Sprintf("Redirect downgrades HTTPS to HTTP at %s", hop.Location))
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+// checkLink requests link with method, following and recording redirects.
+func (c *Crawler) checkLink(ctx context.Context, method string, input Link) (CrawlResponse, error) {
+	start := time.Now()
+	var hops []Redirect
+	ctx = context.WithValue(ctx, redirectChainKey{}, &hops)
+
+	req, err := http.NewRequestWithContext(ctx, method, input.URL, nil)
+	if err != nil {
+		return CrawlResponse{}, err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return CrawlResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	finalURL := resp.Request.URL.String()
+	return CrawlResponse{
+		URL:        input.URL,
+		Origins:    input.Origins,
+		StatusCode: resp.StatusCode,
+		Redirects:  hops,
+		FinalURL:   finalURL,
+		Warnings:   c.opts.Redirects.redirectWarnings(hops, finalURL),
+		Duration:   time.Since(start),
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}, nil
+}
+
+// checkLinkRetry checks link with method like checkLink, retrying responses
+// that ask the client to come back later according to Options.Retry. Network
+// errors are retried as well when retryErrors is set. It returns the number
+// of attempts made together with the outcome of the last one.
+func (c *Crawler) checkLinkRetry(ctx context.Context, method string, input Link, retryErrors bool) (CrawlResponse, int, error) {
+	policy := c.opts.Retry
+	for attempt := 1; ; attempt++ {
+		result, err := c.checkLink(ctx, method, input)
+		if attempt >= policy.Attempts || ctx.Err() != nil {
+			return result, attempt, err
+		}
+
+		var retryAfter time.Duration
+		switch {
+		case err != nil && (!retryErrors || errors.Is(err, ErrRedirectLoop)):
+			return result, attempt, err
+		case err == nil && !isRetryableStatus(result.StatusCode):
+			return result, attempt, nil
+		case err == nil:
+			retryAfter = result.retryAfter
+		}
+
+		wait := policy.backoff(attempt, retryAfter)
+		c.logf("Retrying %s in %v (attempt %d of %d)\n", input.URL, wait.Round(time.Millisecond), attempt+1, policy.Attempts)
+		if sleep(ctx, wait) != nil {
+			return result, attempt, err
+		}
+	}
+}
+
+// finish records the outcome of checking a link for Options.Checkpoint and
+// passes it on to Options.OnResult.
+func (c *Crawler) finish(result LinkResult) {
+	c.progress.linkChecked(result)
+	c.emit(result)
+}
+
+// retryLink is a link whose first check failed with a network error, and
+// the number of attempts that took.
+type retryLink struct {
+	link     Link
+	attempts int
+}
+
+// checkOutcome is what a check worker hands on to be recorded: either a
+// response, a request error or a link to retry with GET.
+type checkOutcome struct {
+	response CrawlResponse
+	reqErr   *RequestError
+	retry    *retryLink
+}
+
+// linkState is what is kept of a unique link while the crawl runs.
+type linkState struct {
+	link Link
+	// seen holds the origins kept in link, so that repeated links, e.g. in
+	// a site's navigation, are folded in without scanning them.
+	seen map[Origin]bool
+	// excluded is set for links Options.Links leaves out, checked once the
+	// outcome of checking the link is recorded and ok if it responded with
+	// a 2xx status.
+	excluded, checked, ok bool
+	// response or reqErr holds the outcome of a link that turned out to be a
+	// problem, for the report.
+	response *CrawlResponse
+	reqErr   *RequestError
+}
+
+func (s *linkState) problem() bool {
+	return s.response != nil || s.reqErr != nil
+}
+
+// checks checks links with a pool of workers as soon as they are found,
+// while pages are still being scraped, recording every outcome from a
+// single goroutine. The pages a link was found on are kept until it is
+// checked, and after that only if it turned out to be a problem or has
+// fragments to verify, so memory grows with the number of unique links
+// rather than with every link on every page.
+type checks struct {
+	c        *Crawler
+	ctx      context.Context
+	queue    chan Link
+	recorded chan struct{}
+
+	mu      sync.Mutex
+	index   map[string]*linkState
+	order   []*linkState
+	retries []retryLink
+	ok      int
+	cached  int
+}
+
+// startChecks starts the workers that check the links passed to add.
+func (c *Crawler) startChecks(ctx context.Context) *checks {
+	k := &checks{
+		c:        c,
+		ctx:      ctx,
+		queue:    make(chan Link),
+		recorded: make(chan struct{}),
+		index:    make(map[string]*linkState),
+	}
+	method := http.MethodHead
+	if c.opts.Method == http.MethodGet {
+		method = http.MethodGet
+	}
+	outcomes := pool(c.opts.Concurrency, k.queue, func(input Link) (checkOutcome, bool) {
+		return c.checkFirst(ctx, method, input)
+	})
+	go func() {
+		defer close(k.recorded)
+		for o := range outcomes {
+			k.mu.Lock()
+			k.record(o, false)
+			k.mu.Unlock()
+		}
+	}()
+	return k
+}
+
+// add merges links into those found so far, folding the origins of a link
+// whose URL was found before into it, and queues every new link for
+// checking unless Options.Links excludes it. It blocks while the workers
+// are busy, which holds back scraping as well.
+func (k *checks) add(links []Link) {
+	var todo []Link
+	k.mu.Lock()
+	for _, link := range links {
+		s, ok := k.index[link.URL]
+		if !ok {
+			s = &linkState{link: Link{URL: link.URL}, excluded: !k.c.linkFilter.allows(link.URL)}
+			k.index[link.URL] = s
+			k.order = append(k.order, s)
+			if !s.excluded {
+				todo = append(todo, Link{URL: link.URL})
+			}
+		}
+		for _, o := range link.Origins {
+			if k.keepsOrigin(s, o) {
+				s.addOrigin(o)
+			}
+		}
+	}
+	// Links checked before the crawl was resumed are not checked again
+	todo = slices.DeleteFunc(todo, func(link Link) bool {
+		o, ok := k.c.progress.resumedCheck(link.URL)
+		if ok {
+			k.record(o, true)
+		}
+		return ok
+	})
+	k.mu.Unlock()
+
+	for _, link := range todo {
+		select {
+		case k.queue <- link:
+		case <-k.ctx.Done():
+			return
+		}
+	}
+}
+
+// keepsOrigin reports whether o is still needed for the link of s: until
+// it is checked, and after that for problems and fragments to verify.
+// k.mu must be held.
+func (k *checks) keepsOrigin(s *linkState, o Origin) bool {
+	if k.c.opts.KeepLinks {
+		return true
+	}
+	return !s.excluded && (!s.checked || s.problem() || isVerifiableFragment(o.Fragment))
+}
+
+// addOrigin adds o to the origins of the link, each distinct origin once.
+func (s *linkState) addOrigin(o Origin) {
+	if s.seen[o] {
+		return
+	}
+	if s.seen == nil {
+		s.seen = make(map[Origin]bool)
+	}
+	s.seen[o] = true
+	s.link.Origins = append(s.link.Origins, o)
+}
+
+// record records the outcome of checking a link, with the pages it was
+// found on so far, and passes it on to Options.OnResult. Outcomes restored
+// from Options.Resume are not recorded for Options.Checkpoint again. k.mu
+// must be held.
+func (k *checks) record(o checkOutcome, resumed bool) {
+	var (
+		s      *linkState
+		result LinkResult
+	)
+	switch {
+	case o.retry != nil:
+		k.retries = append(k.retries, *o.retry)
+		return
+	case o.reqErr != nil:
+		s = k.index[o.reqErr.URL]
+		o.reqErr.Origins = s.link.Origins
+		s.reqErr = o.reqErr
+		result = requestErrorResult(*o.reqErr)
+	default:
+		r := o.response
+		s = k.index[r.URL]
+		r.Origins = s.link.Origins
+		if r.OK {
+			k.ok++
+			s.ok = true
+		}
+		if r.Cached {
+			k.cached++
+		}
+		if !r.OK || len(r.Warnings) > 0 {
+			s.response = &r
+		}
+		result = responseResult(r)
+	}
+	s.checked = true
+	if !s.problem() && !k.c.opts.KeepLinks {
+		s.dropOrigins()
+	}
+
+	if resumed {
+		k.c.emit(result)
+	} else {
+		k.c.finish(result)
+	}
+}
+
+// dropOrigins drops the origins of a link that checked out fine, except
+// those linking to a fragment that is still to be verified.
+func (s *linkState) dropOrigins() {
+	origins := s.link.Origins
+	s.link.Origins, s.seen = nil, nil
+	for _, o := range origins {
+		if isVerifiableFragment(o.Fragment) {
+			s.addOrigin(o)
+		}
+	}
+}
+
+// finish waits for every queued link to be checked, checks the links that
+// failed with a network error again with GET and fills in the links and
+// results of report. No links may be added after it is called.
+func (k *checks) finish(report *Report) {
+	close(k.queue)
+	<-k.recorded
+
+	// Retry with GET for any URLs that failed the initial request
+	if len(k.retries) > 0 {
+		for o := range pool(k.c.opts.Concurrency, feed(k.ctx, k.retries), func(retry retryLink) (checkOutcome, bool) {
+			return k.c.checkAgain(k.ctx, retry)
+		}) {
+			k.mu.Lock()
+			k.record(o, false)
+			k.mu.Unlock()
+		}
+	}
+
+	report.OK, report.Cached = k.ok, k.cached
+	for _, s := range k.order {
+		link := Link{URL: s.link.URL}
+		if k.c.opts.KeepLinks {
+			link = s.link
+		}
+		if s.excluded {
+			report.ExcludedLinks = append(report.ExcludedLinks, link)
+			continue
+		}
+		report.Links = append(report.Links, link)
+
+		switch {
+		case s.reqErr != nil:
+			e := *s.reqErr
+			e.Origins = s.link.Origins
+			report.RequestErrors = append(report.RequestErrors, e)
+		case s.response != nil && !s.response.OK:
+			r := *s.response
+			r.Origins = s.link.Origins
+			report.Broken = append(report.Broken, r)
+		case s.response != nil:
+			r := *s.response
+			r.Origins = s.link.Origins
+			report.Warnings = append(report.Warnings, r)
+		}
+	}
+}
+
+// fragmentTargets returns the links that responded with a 2xx status, with
+// the origins that link to a fragment of them.
+func (k *checks) fragmentTargets() []CrawlResponse {
+	var targets []CrawlResponse
+	for _, s := range k.order {
+		if s.ok && hasFragments(s.link.Origins) {
+			targets = append(targets, CrawlResponse{URL: s.link.URL, Origins: s.link.Origins, OK: true})
+		}
+	}
+	return targets
+}
+
+// checkFirst checks input with method, or takes its result from the cache.
+// It returns false if the check was cut short by cancelling ctx.
+func (c *Crawler) checkFirst(ctx context.Context, method string, input Link) (checkOutcome, bool) {
+	if result, ok := c.cachedResult(input); ok {
+		result.OK = (result.StatusCode >= 200 && result.StatusCode <= 299) || result.StatusCode == 999
+		c.logf("Cached response %d for %s\n", result.StatusCode, input.URL)
+		return checkOutcome{response: result}, true
+	}
+
+	result, attempts, err := c.checkLinkRetry(ctx, method, input, false)
+	if err != nil {
+		if ctx.Err() != nil {
+			return checkOutcome{}, false
+		}
+		c.logln("Request error:", err)
+		// A loop won't resolve itself on retry
+		if errors.Is(err, ErrRedirectLoop) {
+			return checkOutcome{reqErr: &RequestError{
+				Err:      err,
+				URL:      input.URL,
+				Origins:  input.Origins,
+				Attempts: attempts,
+			}}, true
+		}
+		return checkOutcome{retry: &retryLink{input, attempts}}, true
+	}
+	result.Attempts = attempts
+	c.cacheResult(result)
+
+	// Treat LinkedIn's non-standard 999 as OK
+	result.OK = (result.StatusCode >= 200 && result.StatusCode <= 299) || result.StatusCode == 999
+	c.logf("%s response %d for %s\n", method, result.StatusCode, input.URL)
+	return checkOutcome{response: result}, true
+}
+
+// checkAgain checks a link that failed with a network error again with
+// GET, retrying network errors as well. It returns false if the check was
+// cut short by cancelling ctx.
+func (c *Crawler) checkAgain(ctx context.Context, retry retryLink) (checkOutcome, bool) {
+	input := retry.link
+	result, attempts, err := c.checkLinkRetry(ctx, http.MethodGet, input, true)
+	attempts += retry.attempts
+	if err != nil {
+		if ctx.Err() != nil {
+			return checkOutcome{}, false
+		}
+		c.logln(err)
+		return checkOutcome{reqErr: &RequestError{
+			Err:      err,
+			URL:      input.URL,
+			Origins:  input.Origins,
+			Attempts: attempts,
+		}}, true
+	}
+
+	result.Attempts = attempts
+	c.cacheResult(result)
+	result.OK = result.StatusCode >= 200 && result.StatusCode <= 299
+	c.logf("GET response %d for %s\n", result.StatusCode, input.URL)
+	return checkOutcome{response: result}, true
+}
@@ -0,0 +1,397 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ---- redirectTrim -------------------------------------------------------
+
+func TestRedirectTrim(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		numVia    int
+		wantError bool
+	}{
+		{"no redirects", 0, false},
+		{"one redirect", 1, false},
+		{"24 redirects", 24, false},
+		{"25 redirects — limit hit", 25, true},
+		{"30 redirects", 30, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			via := make([]*http.Request, tt.numVia)
+			err := redirectTrim(nil, via)
+			if (err != nil) != tt.wantError {
+				t.Errorf("redirectTrim() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+// ---- checkURLStatus -----------------------------------------------------
+
+// checkURLStatus checks links the way Run does and returns every response,
+// the broken ones and the links that could not be checked.
+func checkURLStatus(ctx context.Context, c *Crawler, links []Link) ([]CrawlResponse, []CrawlResponse, []RequestError) {
+	var responses []CrawlResponse
+	c.opts.OnResult = func(r LinkResult) {
+		if r.Category != CategoryRequestError && r.Category != CategoryRedirectLoop {
+			responses = append(responses, resultResponse(r))
+		}
+	}
+	check := c.startChecks(ctx)
+	check.add(links)
+	report := &Report{}
+	check.finish(report)
+	return responses, report.Broken, report.RequestErrors
+}
+
+func TestCheckURLStatus_AllOK(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	links := []Link{
+		{URL: srv.URL + "/", Origins: []Origin{{URL: "https://example.com/", Text: "Home"}}},
+		{URL: srv.URL + "/about", Origins: []Origin{{URL: "https://example.com/", Text: "About"}}},
+	}
+
+	crawled, urlErrors, requestErrors := checkURLStatus(context.Background(), newTestCrawler(t, Options{Concurrency: 5, Method: "HEAD", Timeout: 5 * time.Second}), links)
+
+	if len(crawled) != 2 {
+		t.Errorf("expected 2 crawled, got %d", len(crawled))
+	}
+	if len(urlErrors) != 0 {
+		t.Errorf("expected 0 url errors, got %d: %v", len(urlErrors), urlErrors)
+	}
+	if len(requestErrors) != 0 {
+		t.Errorf("expected 0 request errors, got %d", len(requestErrors))
+	}
+}
+
+func TestCheckURLStatus_StatusCodeClassification(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		statusCode int
+		wantIsOk   bool
+	}{
+		{"200 OK", 200, true},
+		{"201 Created", 201, true},
+		{"299 edge of 2xx", 299, true},
+		{"301 Moved Permanently", 301, false},
+		{"404 Not Found", 404, false},
+		{"500 Internal Server Error", 500, false},
+		{"999 LinkedIn non-standard", 999, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer srv.Close()
+
+			links := []Link{{URL: srv.URL + "/", Origins: []Origin{{URL: "https://example.com/"}}}}
+			crawled, urlErrors, _ := checkURLStatus(context.Background(), newTestCrawler(t, Options{Concurrency: 1, Method: "HEAD", Timeout: 5 * time.Second}), links)
+
+			if len(crawled) != 1 {
+				t.Fatalf("expected 1 crawled result, got %d", len(crawled))
+			}
+			if crawled[0].OK != tt.wantIsOk {
+				t.Errorf("status %d: isOk = %v, want %v", tt.statusCode, crawled[0].OK, tt.wantIsOk)
+			}
+			if crawled[0].StatusCode != tt.statusCode {
+				t.Errorf("statusCode = %d, want %d", crawled[0].StatusCode, tt.statusCode)
+			}
+			hasURLError := len(urlErrors) > 0
+			if hasURLError == tt.wantIsOk {
+				t.Errorf("status %d: urlErrors presence mismatch (hasURLError=%v, wantIsOk=%v)", tt.statusCode, hasURLError, tt.wantIsOk)
+			}
+		})
+	}
+}
+
+func TestCheckURLStatus_UsesRequestMethod(t *testing.T) {
+	t.Parallel()
+	// mu guards receivedMethod which is written by the httptest server goroutine
+	// and read by the test goroutine.
+	var mu sync.Mutex
+	var receivedMethod string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		receivedMethod = r.Method
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	links := []Link{{URL: srv.URL + "/", Origins: []Origin{{URL: "https://example.com/"}}}}
+
+	t.Run("HEAD method", func(t *testing.T) {
+		checkURLStatus(context.Background(), newTestCrawler(t, Options{Concurrency: 1, Method: "HEAD", Timeout: 5 * time.Second}), links)
+		mu.Lock()
+		got := receivedMethod
+		mu.Unlock()
+		if got != http.MethodHead {
+			t.Errorf("expected HEAD, got %s", got)
+		}
+	})
+	t.Run("GET method", func(t *testing.T) {
+		checkURLStatus(context.Background(), newTestCrawler(t, Options{Concurrency: 1, Method: "GET", Timeout: 5 * time.Second}), links)
+		mu.Lock()
+		got := receivedMethod
+		mu.Unlock()
+		if got != http.MethodGet {
+			t.Errorf("expected GET, got %s", got)
+		}
+	})
+}
+
+// TestCheckURLStatus_HeadFailsRetryWithGet verifies that when a HEAD request
+// fails with a network error, the URL is retried with GET.
+func TestCheckURLStatus_HeadFailsRetryWithGet(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	getCalled := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			// Abruptly close the connection to produce a network-level error.
+			hj, ok := w.(http.Hijacker)
+			if !ok {
+				t.Error("server does not support hijacking")
+				return
+			}
+			conn, _, _ := hj.Hijack()
+			conn.Close()
+			return
+		}
+		// GET succeeds normally.
+		mu.Lock()
+		getCalled++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	links := []Link{{URL: srv.URL + "/", Origins: []Origin{{URL: "https://example.com/"}}}}
+	crawled, _, requestErrors := checkURLStatus(context.Background(), newTestCrawler(t, Options{Concurrency: 1, Method: "HEAD", Timeout: 5 * time.Second}), links)
+
+	mu.Lock()
+	got := getCalled
+	mu.Unlock()
+	if got != 1 {
+		t.Errorf("GET retry called %d times, want 1", got)
+	}
+	if len(requestErrors) != 0 {
+		t.Errorf("expected 0 request errors after successful GET retry, got %d", len(requestErrors))
+	}
+	if len(crawled) != 1 || !crawled[0].OK {
+		t.Errorf("expected 1 successful crawled result, got %+v", crawled)
+	}
+}
+
+// TestCheckURLStatus_BothMethodsFail verifies that when both HEAD and GET
+// network requests fail, the URL ends up in requestErrors.
+func TestCheckURLStatus_BothMethodsFail(t *testing.T) {
+	t.Parallel()
+	// Create a listener, grab its address, then close it so connections are refused.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not create listener: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	links := []Link{{URL: "http://" + addr + "/", Origins: []Origin{{URL: "https://example.com/"}}}}
+	_, _, requestErrors := checkURLStatus(context.Background(), newTestCrawler(t, Options{Concurrency: 1, Method: "HEAD", Timeout: 2 * time.Second}), links)
+
+	if len(requestErrors) != 1 {
+		t.Errorf("expected 1 request error, got %d", len(requestErrors))
+	}
+}
+
+// TestCheckURLStatus_ConcurrencyLimit verifies the semaphore actually limits
+// the number of in-flight requests to the configured concurrentLimit.
+func TestCheckURLStatus_ConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+	const limit = 3
+	var (
+		mu          sync.Mutex
+		inflight    int
+		maxInflight int
+	)
+	// Gate channel lets us control when server responses are released.
+	gate := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inflight++
+		if inflight > maxInflight {
+			maxInflight = inflight
+		}
+		mu.Unlock()
+
+		<-gate // hold until test releases
+
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	var links []Link
+	for i := range 10 {
+		links = append(links, Link{URL: fmt.Sprintf("%s/%d", srv.URL, i)})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		checkURLStatus(context.Background(), newTestCrawler(t, Options{Concurrency: limit, Method: "HEAD", Timeout: 5 * time.Second}), links)
+		close(done)
+	}()
+
+	// Release all gates and wait for completion.
+	for range links {
+		gate <- struct{}{}
+	}
+	<-done
+
+	mu.Lock()
+	got := maxInflight
+	mu.Unlock()
+	if got > limit {
+		t.Errorf("max concurrent requests = %d, want <= %d", got, limit)
+	}
+}
+
+// ---- redirects ----------------------------------------------------------
+
+// newRedirectServer serves a handful of redirect scenarios.
+func newRedirectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/loop-a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop-b", http.StatusFound)
+	})
+	mux.HandleFunc("/loop-b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop-a", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCheckURLStatus_RecordsRedirectChain(t *testing.T) {
+	t.Parallel()
+	srv := newRedirectServer(t)
+
+	c := newTestCrawler(t, Options{Timeout: 5 * time.Second, Redirects: RedirectPolicy{WarnPermanent: true}})
+	crawled, _, _ := checkURLStatus(context.Background(), c, []Link{{URL: srv.URL + "/old"}})
+	if len(crawled) != 1 {
+		t.Fatalf("expected 1 crawled result, got %d", len(crawled))
+	}
+	got := crawled[0]
+
+	want := []Redirect{
+		{URL: srv.URL + "/old", StatusCode: http.StatusMovedPermanently, Location: srv.URL + "/moved"},
+		{URL: srv.URL + "/moved", StatusCode: http.StatusFound, Location: srv.URL + "/new"},
+	}
+	if !slices.Equal(got.Redirects, want) {
+		t.Errorf("Redirects = %+v, want %+v", got.Redirects, want)
+	}
+	if got.FinalURL != srv.URL+"/new" {
+		t.Errorf("FinalURL = %q, want %q", got.FinalURL, srv.URL+"/new")
+	}
+	if !got.OK {
+		t.Error("expected redirected link to be OK")
+	}
+	if len(got.Warnings) != 1 || !strings.Contains(got.Warnings[0], srv.URL+"/new") {
+		t.Errorf("Warnings = %v, want a permanent redirect warning naming the new URL", got.Warnings)
+	}
+}
+
+func TestCheckURLStatus_RedirectWarningsFollowPolicy(t *testing.T) {
+	t.Parallel()
+	srv := newRedirectServer(t)
+
+	tests := []struct {
+		name      string
+		path      string
+		policy    RedirectPolicy
+		wantWarns int
+	}{
+		{"permanent, warnings off", "/old", RedirectPolicy{}, 0},
+		{"permanent, warnings on", "/old", RedirectPolicy{WarnPermanent: true}, 1},
+		{"temporary redirect never warns", "/temporary", RedirectPolicy{WarnPermanent: true}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCrawler(t, Options{Timeout: 5 * time.Second, Redirects: tt.policy})
+			crawled, _, _ := checkURLStatus(context.Background(), c, []Link{{URL: srv.URL + tt.path}})
+			if len(crawled) != 1 {
+				t.Fatalf("expected 1 crawled result, got %d", len(crawled))
+			}
+			if len(crawled[0].Warnings) != tt.wantWarns {
+				t.Errorf("got %d warnings, want %d: %v", len(crawled[0].Warnings), tt.wantWarns, crawled[0].Warnings)
+			}
+		})
+	}
+}
+
+func TestCheckURLStatus_RedirectLoop(t *testing.T) {
+	t.Parallel()
+	srv := newRedirectServer(t)
+
+	c := newTestCrawler(t, Options{Timeout: 5 * time.Second})
+	crawled, _, requestErrors := checkURLStatus(context.Background(), c, []Link{{URL: srv.URL + "/loop-a"}})
+	if len(crawled) != 0 {
+		t.Errorf("expected no crawled results, got %+v", crawled)
+	}
+	if len(requestErrors) != 1 || !errors.Is(requestErrors[0].Err, ErrRedirectLoop) {
+		t.Errorf("expected a single redirect loop error, got %+v", requestErrors)
+	}
+}
+
+func TestRedirectWarnings_Downgrade(t *testing.T) {
+	t.Parallel()
+	hops := []Redirect{
+		{URL: "https://example.com/a", StatusCode: http.StatusFound, Location: "http://example.com/a"},
+	}
+	if got := (RedirectPolicy{}).redirectWarnings(hops, "http://example.com/a"); len(got) != 0 {
+		t.Errorf("expected no warnings with downgrade checks off, got %v", got)
+	}
+	got := RedirectPolicy{WarnDowngrade: true}.redirectWarnings(hops, "http://example.com/a")
+	if len(got) != 1 || !strings.Contains(got[0], "HTTPS to HTTP") {
+		t.Errorf("expected a downgrade warning, got %v", got)
+	}
+}
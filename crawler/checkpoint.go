@@ -0,0 +1,318 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCheckpointInterval is how often progress is saved when
+// Options.Checkpoint is set and Options.CheckpointInterval is not.
+const DefaultCheckpointInterval = time.Minute
+
+// Checkpoint is the progress of a crawl, saved to Options.Checkpoint so an
+// interrupted crawl can be continued with Options.Resume.
+type Checkpoint struct {
+	Entrypoint string `json:"entrypoint"`
+	// Settings are the options the crawl was started with. It can only be
+	// resumed with the same ones.
+	Settings  CrawlSettings `json:"settings"`
+	Start     time.Time     `json:"start"`
+	Sitemaps  []string      `json:"sitemaps,omitempty"`
+	Discovery Discovery     `json:"discovery,omitempty"`
+	// Pages lists the pages to scrape once the sitemaps have been read, and
+	// is nil before that. In spider mode it stays nil, as pages are found
+	// while crawling.
+	Pages         []string `json:"pages"`
+	Unchanged     []string `json:"unchanged,omitempty"`
+	ExcludedPages []string `json:"excluded_pages,omitempty"`
+	RobotsSkipped []string `json:"robots_skipped,omitempty"`
+	// Scraped holds the links found on every page scraped so far, and on
+	// the unchanged pages of an incremental crawl.
+	Scraped map[string][]Link `json:"scraped"`
+	// Checked holds the outcome of every link checked so far.
+	Checked []LinkResult `json:"checked"`
+}
+
+// CrawlSettings are the options that decide which pages are scraped and
+// which links are checked, and how their results are classified.
+type CrawlSettings struct {
+	Kinds          []Kind         `json:"kinds"`
+	Method         string         `json:"method"`
+	Spider         bool           `json:"spider,omitempty"`
+	MaxDepth       int            `json:"max_depth,omitempty"`
+	MaxPages       int            `json:"max_pages,omitempty"`
+	AllowedHosts   []string       `json:"allowed_hosts,omitempty"`
+	IncludePages   []string       `json:"include_pages,omitempty"`
+	ExcludePages   []string       `json:"exclude_pages,omitempty"`
+	IncludeLinks   []string       `json:"include_links,omitempty"`
+	ExcludeLinks   []string       `json:"exclude_links,omitempty"`
+	IgnoreRobots   bool           `json:"ignore_robots,omitempty"`
+	CheckFragments bool           `json:"check_fragments,omitempty"`
+	Redirects      RedirectPolicy `json:"redirects"`
+}
+
+// crawlSettings returns the settings of opts, once New applied defaults.
+func (opts Options) crawlSettings() CrawlSettings {
+	return CrawlSettings{
+		Kinds:          opts.Kinds,
+		Method:         opts.Method,
+		Spider:         opts.Spider,
+		MaxDepth:       opts.MaxDepth,
+		MaxPages:       opts.MaxPages,
+		AllowedHosts:   opts.AllowedHosts,
+		IncludePages:   opts.Pages.Include,
+		ExcludePages:   opts.Pages.Exclude,
+		IncludeLinks:   opts.Links.Include,
+		ExcludeLinks:   opts.Links.Exclude,
+		IgnoreRobots:   opts.IgnoreRobots,
+		CheckFragments: opts.CheckFragments,
+		Redirects:      opts.Redirects,
+	}
+}
+
+// equal reports whether s and other select and check the same links. Empty
+// and missing lists are the same.
+func (s CrawlSettings) equal(other CrawlSettings) bool {
+	return slices.Equal(s.Kinds, other.Kinds) &&
+		s.Method == other.Method &&
+		s.Spider == other.Spider &&
+		s.MaxDepth == other.MaxDepth &&
+		s.MaxPages == other.MaxPages &&
+		slices.Equal(s.AllowedHosts, other.AllowedHosts) &&
+		slices.Equal(s.IncludePages, other.IncludePages) &&
+		slices.Equal(s.ExcludePages, other.ExcludePages) &&
+		slices.Equal(s.IncludeLinks, other.IncludeLinks) &&
+		slices.Equal(s.ExcludeLinks, other.ExcludeLinks) &&
+		s.IgnoreRobots == other.IgnoreRobots &&
+		s.CheckFragments == other.CheckFragments &&
+		s.Redirects == other.Redirects
+}
+
+// LoadCheckpoint reads a checkpoint file saved by a crawl with
+// Options.Checkpoint set.
+func LoadCheckpoint(filename string) (*Checkpoint, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &cp, nil
+}
+
+// writeFileAtomic writes data to filename through a temporary file, so the
+// previous content is only replaced once the new one is complete.
+func writeFileAtomic(filename string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// progress tracks the checkpoint of a running crawl. Its recording methods
+// do nothing on a nil progress, so the crawl calls them whether or not
+// checkpoints are used.
+type progress struct {
+	mu sync.Mutex
+	cp Checkpoint
+	// resumed holds the checks restored from Options.Resume, by URL.
+	resumed map[string]LinkResult
+	// record is set when the checkpoint is saved to a file. Without one,
+	// scraped pages and checked links are not kept a second time.
+	record bool
+}
+
+// newProgress starts tracking a crawl of entrypoint with settings,
+// continuing from resume if it is set. Only with record set are the pages
+// scraped and the links checked kept for saving.
+func newProgress(entrypoint string, settings CrawlSettings, resume *Checkpoint, record bool) *progress {
+	p := &progress{
+		cp:      Checkpoint{Entrypoint: entrypoint, Settings: settings, Scraped: make(map[string][]Link)},
+		resumed: make(map[string]LinkResult),
+		record:  record,
+	}
+	if resume != nil {
+		p.cp = *resume
+		if p.cp.Scraped == nil {
+			p.cp.Scraped = make(map[string][]Link)
+		}
+		for _, r := range resume.Checked {
+			p.resumed[r.URL] = r
+		}
+	}
+	return p
+}
+
+// started records when the crawl started, unless it is resumed.
+func (p *progress) started(start time.Time) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cp.Start.IsZero() {
+		p.cp.Start = start
+	}
+}
+
+// pagesSelected records the pages to scrape and the report fields that
+// describe how they were selected.
+func (p *progress) pagesSelected(report *Report, pages []string, unchanged map[string][]Link) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cp.Sitemaps, p.cp.Discovery = report.Sitemaps, report.Discovery
+	p.cp.Pages = append([]string{}, pages...)
+	p.cp.Unchanged = report.Unchanged
+	p.cp.ExcludedPages = report.ExcludedPages
+	p.cp.RobotsSkipped = report.RobotsSkipped
+	for page, links := range unchanged {
+		p.cp.Scraped[page] = links
+	}
+}
+
+// scrapedLinks returns the links recorded for page, e.g. before the crawl
+// was resumed.
+func (p *progress) scrapedLinks(page string) ([]Link, bool) {
+	if p == nil {
+		return nil, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	links, ok := p.cp.Scraped[page]
+	return links, ok
+}
+
+func (p *progress) pageScraped(page string, links []Link) {
+	if p == nil || !p.record {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cp.Scraped[page] = links
+}
+
+func (p *progress) linkChecked(result LinkResult) {
+	if p == nil || !p.record {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cp.Checked = append(p.cp.Checked, result)
+}
+
+// save writes the checkpoint to filename.
+func (p *progress) save(filename string) error {
+	p.mu.Lock()
+	data, err := json.Marshal(p.cp)
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data)
+}
+
+// saveCheckpoints saves the checkpoint every Options.CheckpointInterval
+// until stop is closed.
+func (c *Crawler) saveCheckpoints(stop <-chan struct{}) {
+	ticker := time.NewTicker(c.opts.CheckpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := c.progress.save(c.opts.Checkpoint); err != nil {
+				c.logf("Failed to save checkpoint: %v\n", err)
+			}
+		}
+	}
+}
+
+// resumedPages restores the pages selected before the crawl was resumed
+// into report. It returns the pages to scrape and the links of unchanged
+// pages, or false if the pages were not selected yet.
+func (c *Crawler) resumedPages(report *Report) ([]string, []Link, bool) {
+	if c.opts.Resume == nil || c.opts.Resume.Pages == nil {
+		return nil, nil, false
+	}
+	cp := c.opts.Resume
+	report.Sitemaps, report.Discovery = cp.Sitemaps, cp.Discovery
+	report.Unchanged = cp.Unchanged
+	report.ExcludedPages = cp.ExcludedPages
+	report.RobotsSkipped = cp.RobotsSkipped
+
+	var reused []Link
+	for _, page := range cp.Unchanged {
+		reused = append(reused, cp.Scraped[page]...)
+	}
+	c.logf("Resuming the crawl started %s: %d of %d page(s) and %d link(s) were done\n",
+		cp.Start.Format(time.RFC3339), len(cp.Scraped)-len(cp.Unchanged), len(cp.Pages), len(cp.Checked))
+	return cp.Pages, reused, true
+}
+
+// resumedCheck returns the outcome of checking the link to url restored
+// from Options.Resume, if it was checked before.
+func (p *progress) resumedCheck(url string) (checkOutcome, bool) {
+	if p == nil {
+		return checkOutcome{}, false
+	}
+	r, ok := p.resumed[url]
+	if !ok {
+		return checkOutcome{}, false
+	}
+	switch r.Category {
+	case CategoryRequestError, CategoryRedirectLoop:
+		e := resultRequestError(r)
+		return checkOutcome{reqErr: &e}, true
+	}
+	return checkOutcome{response: resultResponse(r)}, true
+}
+
+// resultResponse turns a result recorded in a checkpoint back into the
+// response it was made from.
+func resultResponse(r LinkResult) CrawlResponse {
+	return CrawlResponse{
+		URL:        r.URL,
+		Origins:    r.Origins,
+		StatusCode: r.StatusCode,
+		OK:         r.Category != CategoryBroken,
+		Redirects:  r.Redirects,
+		FinalURL:   r.FinalURL,
+		Warnings:   r.Warnings,
+		Duration:   time.Duration(r.DurationMS * float64(time.Millisecond)),
+		Attempts:   r.Attempts,
+		Cached:     r.Cached,
+	}
+}
+
+// resultRequestError turns a request error recorded in a checkpoint back
+// into a RequestError. Redirect loops still match ErrRedirectLoop.
+func resultRequestError(r LinkResult) RequestError {
+	err := errors.New(r.Error)
+	if r.Category == CategoryRedirectLoop {
+		msg := strings.TrimSuffix(r.Error, ErrRedirectLoop.Error())
+		err = fmt.Errorf("%s%w", msg, ErrRedirectLoop)
+	}
+	return RequestError{Err: err, URL: r.URL, Origins: r.Origins, Attempts: r.Attempts}
+}
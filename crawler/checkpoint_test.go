@@ -0,0 +1,186 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ---- checkpoints --------------------------------------------------------
+
+func TestRun_CheckpointAndResume(t *testing.T) {
+	t.Parallel()
+	var (
+		pageHits, okHits atomic.Int32
+		hang             atomic.Bool
+		okDone           = make(chan struct{})
+		once             sync.Once
+	)
+	hang.Store(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%[1]s/a</loc></url><url><loc>%[1]s/b</loc></url></urlset>`, srv.URL)
+		case "/a", "/b":
+			pageHits.Add(1)
+			fmt.Fprint(w, `<html><body><a href="/ok">OK</a><a href="/hang">Hang</a></body></html>`)
+		case "/ok":
+			okHits.Add(1)
+		case "/hang":
+			if !hang.Load() {
+				return
+			}
+			// Interrupt the crawl once /ok is done, while this check is
+			// still running
+			<-okDone
+			cancel()
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	filename := filepath.Join(t.TempDir(), "checkpoint.json")
+	c, err := New(srv.URL+"/sitemap.xml", Options{
+		Timeout:    5 * time.Second,
+		Retry:      RetryPolicy{Attempts: 1},
+		Checkpoint: filename,
+		OnResult: func(r LinkResult) {
+			if r.URL == srv.URL+"/ok" {
+				once.Do(func() { close(okDone) })
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := c.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+
+	cp, err := LoadCheckpoint(filename)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	// Links are checked while pages are scraped, so the second page may
+	// not be done yet
+	if len(cp.Pages) != 2 || len(cp.Scraped) == 0 {
+		t.Errorf("checkpoint has %d pages and %d scraped, want 2 and at least 1", len(cp.Pages), len(cp.Scraped))
+	}
+	if len(cp.Checked) != 1 || cp.Checked[0].URL != srv.URL+"/ok" {
+		t.Fatalf("checkpoint has checked %+v, want just /ok", cp.Checked)
+	}
+
+	// Resuming only checks what is left
+	hang.Store(false)
+	c, err = New(srv.URL+"/sitemap.xml", Options{
+		Timeout:    5 * time.Second,
+		Retry:      RetryPolicy{Attempts: 1},
+		Checkpoint: filename,
+		Resume:     cp,
+		KeepLinks:  true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("resumed Run() error = %v", err)
+	}
+	if pageHits.Load() != 2 || okHits.Load() != 1 {
+		t.Errorf("got %d page and %d /ok requests in total, want 2 and 1", pageHits.Load(), okHits.Load())
+	}
+	if len(report.Pages) != 2 || report.OK != 2 || len(report.Broken) != 0 {
+		t.Errorf("got %d pages, %d OK and %d broken, want 2, 2 and 0", len(report.Pages), report.OK, len(report.Broken))
+	}
+	for _, r := range report.Links {
+		if len(r.Origins) != 2 {
+			t.Errorf("%s has %d origins, want 2", r.URL, len(r.Origins))
+		}
+	}
+	if _, err := os.Stat(filename); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint still exists after a complete crawl: %v", err)
+	}
+}
+
+func TestRun_ResumeWithoutCheckpointKeepsNoProgress(t *testing.T) {
+	t.Parallel()
+	srv := newSiteServer(t)
+	opts := Options{Timeout: 5 * time.Second, Checkpoint: filepath.Join(t.TempDir(), "checkpoint.json")}
+	c, err := New(srv.URL+"/sitemap.xml", opts)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	cp := c.progress.cp
+
+	// Without a file to save to, nothing is kept beside the report
+	opts.Checkpoint, opts.Resume = "", &cp
+	if c, err = New(srv.URL+"/sitemap.xml", opts); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.OK == 0 {
+		t.Fatal("got no results")
+	}
+	if got := c.progress.cp; len(got.Scraped) != 0 || len(got.Checked) != 0 {
+		t.Errorf("progress kept %d scraped pages and %d checked links, want none", len(got.Scraped), len(got.Checked))
+	}
+}
+
+func TestNew_ResumeOtherEntrypoint(t *testing.T) {
+	t.Parallel()
+	_, err := New("https://example.com/sitemap.xml", Options{Resume: &Checkpoint{Entrypoint: "https://example.org/sitemap.xml"}})
+	if err == nil {
+		t.Error("New() with a checkpoint of another crawl: expected an error")
+	}
+}
+
+func TestNew_ResumeOtherSettings(t *testing.T) {
+	t.Parallel()
+	const entrypoint = "https://example.com/sitemap.xml"
+	c, err := New(entrypoint, Options{Checkpoint: filepath.Join(t.TempDir(), "checkpoint.json")})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	cp := c.progress.cp
+
+	if _, err := New(entrypoint, Options{Resume: &cp}); err != nil {
+		t.Errorf("New() with the same options: %v", err)
+	}
+	for name, opts := range map[string]Options{
+		"kinds":     {Kinds: []Kind{KindAnchor, KindImage}},
+		"filters":   {Links: URLFilter{Exclude: []string{"*/admin/*"}}},
+		"fragments": {CheckFragments: true},
+	} {
+		opts.Resume = &cp
+		if _, err := New(entrypoint, opts); err == nil {
+			t.Errorf("New() with other %s: expected an error", name)
+		}
+	}
+}
+
+func TestResultRequestError_RedirectLoop(t *testing.T) {
+	t.Parallel()
+	original := RequestError{Err: fmt.Errorf("Head %q: %w", "https://example.com/", ErrRedirectLoop), URL: "https://example.com/"}
+	restored := resultRequestError(requestErrorResult(original))
+	if !errors.Is(restored.Err, ErrRedirectLoop) {
+		t.Errorf("restored error %v does not match ErrRedirectLoop", restored.Err)
+	}
+	if restored.Err.Error() != original.Err.Error() {
+		t.Errorf("restored error = %q, want %q", restored.Err, original.Err)
+	}
+}
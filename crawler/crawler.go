@@ -0,0 +1,498 @@
+// Package crawler crawls websites for in- and outbound links, verifying
+// that every link gives a 2xx-response back. Pages are discovered through
+// the site's sitemap.xml, every page is scraped for links and each unique
+// link is checked once, as soon as it is found.
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default option values used when the corresponding Options field is left
+// at its zero value.
+const (
+	DefaultConcurrency = 10
+	DefaultUserAgent   = "Golang Link Crawler/1.0"
+	DefaultMethod      = http.MethodHead
+	DefaultTimeout     = 60 * time.Second
+)
+
+// ErrAborted is returned by Run when Options.Confirm declines to continue
+// with scraping the pages found and checking their links.
+var ErrAborted = errors.New("crawl aborted before checking links")
+
+// Origin is a page that links to a URL, together with the link text used
+// and the element the link was found in.
+type Origin struct {
+	URL  string `json:"url"`
+	Text string `json:"text"`
+	Kind Kind   `json:"kind,omitempty"`
+	// Source is the element and attribute holding the link, e.g. "img[src]".
+	Source string `json:"source,omitempty"`
+	// Fragment is the #fragment the link points at, without the leading #.
+	// It is only recorded when fragments are checked.
+	Fragment string `json:"fragment,omitempty"`
+}
+
+// Link is a unique link target and every page it was found on.
+type Link struct {
+	URL     string   `json:"url"`
+	Origins []Origin `json:"origins"`
+}
+
+// CrawlResponse is the outcome of checking a single link.
+type CrawlResponse struct {
+	URL        string
+	Origins    []Origin
+	StatusCode int
+	OK         bool
+	// Redirects lists every redirect followed, in order.
+	Redirects []Redirect
+	// FinalURL is the URL that answered after following all redirects.
+	FinalURL string
+	// Warnings lists problems that do not make the link broken, such as
+	// permanent redirects, according to Options.Redirects.
+	Warnings []string
+	// Duration is how long the check took, including redirects.
+	Duration time.Duration
+	// Attempts is the number of requests it took to get this response,
+	// including retries.
+	Attempts int
+	// Cached is set when the result was taken from Options.Cache instead of
+	// checking the link again.
+	Cached bool
+
+	// retryAfter is the wait asked for by the response's Retry-After header.
+	retryAfter time.Duration
+}
+
+// RequestError describes a link that could not be checked at all, e.g.
+// because of a network failure.
+type RequestError struct {
+	Err     error
+	URL     string
+	Origins []Origin
+	// Attempts is the number of requests made before giving up.
+	Attempts int
+}
+
+func (e RequestError) Error() string {
+	return fmt.Sprintf("%s: %v", e.URL, e.Err)
+}
+
+func (e RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Options configures a Crawler.
+type Options struct {
+	// Concurrency limits the number of concurrent requests.
+	Concurrency int
+	// Method is the initial request method used for checking links, HEAD or GET.
+	Method string
+	// Timeout is the timeout for each individual request.
+	Timeout time.Duration
+	// UserAgent is sent with every request.
+	UserAgent string
+	// Redirects configures which redirects are reported as warnings.
+	Redirects RedirectPolicy
+	// Retry configures how links answering 429 or 503, or failing with a
+	// network error, are retried.
+	Retry RetryPolicy
+	// Kinds selects which kinds of resources are checked. Only anchors are
+	// checked when empty.
+	Kinds []Kind
+	// Spider crawls the site by following internal links breadth-first from
+	// the entrypoint page instead of reading the site's sitemaps.
+	Spider bool
+	// MaxDepth limits how many links away from the entrypoint the spider
+	// goes. Zero means no limit.
+	MaxDepth int
+	// MaxPages limits the number of pages the spider fetches. Zero means no
+	// limit.
+	MaxPages int
+	// AllowedHosts lists hosts besides the entrypoint's own that belong to
+	// the site. The spider only crawls these hosts; links to any other host
+	// are only checked, never crawled.
+	AllowedHosts []string
+	// SiteLimits throttles requests to each of the site's own hosts (the
+	// entrypoint's host and AllowedHosts), ExternalLimits requests to every
+	// other host. Each host is limited separately, on top of Concurrency.
+	SiteLimits     HostLimits
+	ExternalLimits HostLimits
+	// Pages selects which of the pages found are scraped for links, and Links
+	// which of the links found on them are checked.
+	Pages URLFilter
+	Links URLFilter
+	// Credentials lists headers and credentials to send to specific hosts,
+	// e.g. basic auth for a staging site.
+	Credentials []HostCredentials
+	// Jar, if set, holds cookies to send, e.g. from LoadCookieFile. Cookies
+	// set by responses are stored in it as well.
+	Jar http.CookieJar
+	// Checkpoint, if set, is the file the progress of the crawl is saved to
+	// every CheckpointInterval and when Run is cancelled, see LoadCheckpoint.
+	Checkpoint         string
+	CheckpointInterval time.Duration
+	// Resume, if set, continues the crawl saved in a checkpoint: pages and
+	// links already done are taken from it instead of being fetched again.
+	// The checkpoint must be of a crawl of the same entrypoint with the same
+	// CrawlSettings.
+	Resume *Checkpoint
+	// Previous, if set, is the state of an earlier run, see NewState. Pages
+	// whose sitemap lastmod is older than that run are not scraped again;
+	// the links found on them then are checked instead. Pages without a
+	// lastmod are always scraped. Ignored in spider mode.
+	Previous *State
+	// Cache, if set, keeps results of third-party links and the links of
+	// pages across runs, see OpenCache. Closing it is up to the caller.
+	Cache *Cache
+	// Baseline, if set, lists accepted problems that are left out of the
+	// report and of the results passed to OnResult.
+	Baseline *Baseline
+	// IgnoreRobots fetches pages even if the site's robots.txt disallows
+	// them for UserAgent, e.g. for crawling one's own staging site. Crawl
+	// delays from robots.txt are ignored as well.
+	IgnoreRobots bool
+	// CheckFragments verifies that links to #fragments on the site's own
+	// pages point at an element with a matching id or <a name>.
+	CheckFragments bool
+	// OnResult, if set, is called for every link as soon as its result is
+	// known, e.g. to stream results to a file. Its origins are the pages the
+	// link was found on so far; the Report lists problems with all of them.
+	// Calls are never concurrent.
+	OnResult func(LinkResult)
+	// KeepLinks keeps every page each link was found on in Report.Links and
+	// Report.ExcludedLinks, as NewState needs. Otherwise only problems keep
+	// their pages once checked, so memory does not grow with them.
+	KeepLinks bool
+	// Log receives progress output. Nothing is written when nil.
+	Log io.Writer
+	// Confirm, if set, is called with the number of pages to scrape once
+	// they are known; in spider mode that is only the entrypoint. Returning
+	// false stops the crawl before any page is scraped and Run returns
+	// ErrAborted.
+	Confirm func(pages int) bool
+}
+
+// Report holds the results of a crawl.
+type Report struct {
+	Entrypoint string
+	Start      time.Time
+	Duration   time.Duration
+	// Sitemaps lists the sitemaps that were crawled and Discovery how they
+	// were found.
+	Sitemaps  []string
+	Discovery Discovery
+	// SitemapPages lists every page found in the sitemaps, with their
+	// metadata. It is empty in spider mode.
+	SitemapPages []Page
+	// Pages lists every page that was scraped for links.
+	Pages []string
+	// Unchanged lists the pages that were not scraped because they did not
+	// change since Options.Previous; their links were taken from it.
+	Unchanged []string
+	// RobotsSkipped lists pages that were not scraped because robots.txt
+	// disallows them.
+	RobotsSkipped []string
+	// ExcludedPages lists pages that were not scraped and ExcludedLinks links
+	// that were not checked because of Options.Pages and Options.Links.
+	ExcludedPages []string
+	ExcludedLinks []Link
+	// Suppressed lists the problems accepted by Options.Baseline, with the
+	// pages they were accepted on.
+	Suppressed []LinkResult
+	// Links lists every unique link found on those pages, with the pages
+	// they were found on only if Options.KeepLinks is set.
+	Links []Link
+	// OK counts the links that responded with a 2xx status, including those
+	// with Warnings, and Cached the responses taken from Options.Cache. Only
+	// problems are kept; every result is passed to Options.OnResult.
+	OK     int
+	Cached int
+	// Broken lists the links that did not respond with a 2xx status.
+	Broken []CrawlResponse
+	// Warnings lists the links that responded with a 2xx status but raised
+	// warnings, e.g. about their redirects.
+	Warnings []CrawlResponse
+	// RequestErrors lists links that could not be checked at all.
+	RequestErrors []RequestError
+	// MissingAnchors lists links to #fragments that do not exist in the
+	// target page. Only filled in when Options.CheckFragments is set.
+	MissingAnchors []MissingAnchor
+}
+
+// Crawler checks every link on the pages listed in a site's sitemaps, or on
+// the pages reachable from the entrypoint in spider mode.
+type Crawler struct {
+	entrypoint string
+	opts       Options
+	client     *http.Client
+	log        io.Writer
+	// hosts is the set of hosts considered internal to the site.
+	hosts     map[string]bool
+	transport *throttledTransport
+
+	pageFilter *urlFilter
+	linkFilter *urlFilter
+
+	// progress records what has been done for Options.Checkpoint and holds
+	// what was done before for Options.Resume. It is nil if neither is set.
+	progress *progress
+
+	// robots caches the robots.txt of every site origin fetched from.
+	robotsMu sync.Mutex
+	robots   map[string]*robotsEntry
+}
+
+// New returns a Crawler for entrypoint, which is either the URL of a sitemap
+// or the bare URL of a site whose sitemaps are discovered through robots.txt.
+func New(entrypoint string, opts Options) (*Crawler, error) {
+	if _, err := url.ParseRequestURI(entrypoint); err != nil {
+		return nil, err
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.Method == "" {
+		opts.Method = DefaultMethod
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	if len(opts.Kinds) == 0 {
+		opts.Kinds = []Kind{KindAnchor}
+	}
+	if opts.Retry.Attempts <= 0 {
+		opts.Retry.Attempts = DefaultRetryAttempts
+	}
+	if opts.Retry.Delay <= 0 {
+		opts.Retry.Delay = DefaultRetryDelay
+	}
+	if opts.Retry.MaxDelay <= 0 {
+		opts.Retry.MaxDelay = DefaultRetryMaxDelay
+	}
+	if opts.SiteLimits.Concurrency <= 0 {
+		opts.SiteLimits.Concurrency = DefaultSiteHostConcurrency
+	}
+	if opts.ExternalLimits.Concurrency <= 0 {
+		opts.ExternalLimits.Concurrency = DefaultExternalHostConcurrency
+	}
+
+	if opts.Checkpoint != "" && opts.CheckpointInterval <= 0 {
+		opts.CheckpointInterval = DefaultCheckpointInterval
+	}
+	if opts.Resume != nil && opts.Resume.Entrypoint != entrypoint {
+		return nil, fmt.Errorf("checkpoint is of a crawl of %s, not %s", opts.Resume.Entrypoint, entrypoint)
+	}
+	settings := opts.crawlSettings()
+	if opts.Resume != nil && !opts.Resume.Settings.equal(settings) {
+		return nil, errors.New("checkpoint is of a crawl with other options, such as other kinds of links or URL filters")
+	}
+
+	logOut := opts.Log
+	if logOut == nil {
+		logOut = io.Discard
+	}
+
+	pageFilter, err := opts.Pages.compile()
+	if err != nil {
+		return nil, err
+	}
+	linkFilter, err := opts.Links.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	var prog *progress
+	if opts.Checkpoint != "" || opts.Resume != nil {
+		prog = newProgress(entrypoint, settings, opts.Resume, opts.Checkpoint != "")
+	}
+
+	hosts := allowedHosts(entrypoint, opts.AllowedHosts)
+	base := newAuthTransport(http.DefaultTransport, entrypoint, opts.AllowedHosts, opts.Credentials)
+	transport := newThrottledTransport(base, opts.Timeout, hosts, opts.SiteLimits, opts.ExternalLimits)
+	return &Crawler{
+		entrypoint: entrypoint,
+		opts:       opts,
+		client: &http.Client{
+			Transport:     transport,
+			CheckRedirect: checkRedirect,
+			Jar:           opts.Jar,
+		},
+		log:        logOut,
+		hosts:      hosts,
+		transport:  transport,
+		pageFilter: pageFilter,
+		linkFilter: linkFilter,
+		robots:     make(map[string]*robotsEntry),
+		progress:   prog,
+	}, nil
+}
+
+// Run fetches the sitemaps (or spiders the site when Options.Spider is set),
+// scrapes the pages found and checks each unique link as soon as it is
+// found, while the remaining pages are still being scraped. If
+// ctx is cancelled or its deadline passes, Run stops issuing new requests and
+// returns the partial report together with the context's error. With
+// Options.Checkpoint set, the progress made so far is then saved for
+// Options.Resume; a crawl that runs to the end removes the checkpoint.
+func (c *Crawler) Run(ctx context.Context) (*Report, error) {
+	defer c.client.CloseIdleConnections()
+	if c.opts.Checkpoint == "" {
+		return c.run(ctx)
+	}
+
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		c.saveCheckpoints(stop)
+	}()
+	report, err := c.run(ctx)
+	close(stop)
+	<-stopped
+
+	if ctx.Err() != nil {
+		if err := c.progress.save(c.opts.Checkpoint); err != nil {
+			c.logf("Failed to save checkpoint: %v\n", err)
+		} else {
+			c.logln("Progress saved to", c.opts.Checkpoint)
+		}
+	} else if err := os.Remove(c.opts.Checkpoint); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		c.logf("Failed to remove checkpoint: %v\n", err)
+	}
+	return report, err
+}
+
+func (c *Crawler) run(ctx context.Context) (*Report, error) {
+	report := &Report{
+		Entrypoint: c.entrypoint,
+		Start:      time.Now(),
+	}
+	c.progress.started(report.Start)
+
+	var (
+		pages  = []string{c.entrypoint}
+		reused []Link
+	)
+	if c.opts.Spider {
+		report.Discovery = DiscoverySpider
+	} else {
+		var err error
+		if pages, reused, err = c.sitemapPages(ctx, report); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		report.Duration = time.Since(report.Start)
+		return report, err
+	}
+	if c.opts.Confirm != nil && !c.opts.Confirm(len(pages)) {
+		report.Duration = time.Since(report.Start)
+		return report, ErrAborted
+	}
+
+	check := c.startChecks(ctx)
+	if c.opts.Spider {
+		c.spider(ctx, report, check)
+	} else {
+		report.Pages = pages
+		c.scrapePages(ctx, pages, func(_ string, pageLinks []Link) {
+			check.add(pageLinks)
+		})
+		check.add(reused)
+	}
+	check.finish(report)
+	c.logln("A total of", len(report.Links), "links were found in", len(report.Pages), "pages")
+	if len(report.ExcludedPages) > 0 || len(report.ExcludedLinks) > 0 {
+		c.logf("Excluded %d page(s) and %d link(s) by pattern\n", len(report.ExcludedPages), len(report.ExcludedLinks))
+	}
+
+	if c.opts.CheckFragments {
+		report.MissingAnchors = c.checkFragments(ctx, check.fragmentTargets())
+	}
+	if c.opts.Baseline != nil {
+		report.applyBaseline(c.opts.Baseline, time.Now())
+	}
+	report.Duration = time.Since(report.Start)
+	return report, ctx.Err()
+}
+
+// sitemapPages reads the sitemaps, or restores them from Options.Resume, and
+// returns the pages to scrape and the links of pages unchanged since
+// Options.Previous.
+func (c *Crawler) sitemapPages(ctx context.Context, report *Report) ([]string, []Link, error) {
+	if pages, reused, ok := c.resumedPages(report); ok {
+		return pages, reused, nil
+	}
+
+	sitemaps, discovery, err := c.discoverSitemaps(ctx, c.entrypoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.Sitemaps, report.Discovery = sitemaps, discovery
+	c.logf("Using %d sitemap(s) found via %s: %s\n", len(sitemaps), discovery, strings.Join(sitemaps, ", "))
+
+	entries, err := c.getSitemaps(ctx, sitemaps)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.SitemapPages = entries
+	pages := c.selectPages(ctx, report, pageURLs(entries))
+	unchanged := make(map[string][]Link)
+	var reused []Link
+	if c.opts.Previous != nil {
+		pages, reused = c.reusePages(report, pages)
+		for _, page := range report.Unchanged {
+			unchanged[page] = c.opts.Previous.Pages[page]
+		}
+		c.logf("%d page(s) unchanged since %s, scraping %d\n", len(report.Unchanged), c.opts.Previous.Start.Format(time.RFC3339), len(pages))
+	}
+	if ctx.Err() == nil {
+		c.progress.pagesSelected(report, pages, unchanged)
+	}
+	return pages, reused, nil
+}
+
+// selectPages returns the pages to scrape, recording those excluded by
+// Options.Pages or robots.txt in report.
+func (c *Crawler) selectPages(ctx context.Context, report *Report, pages []string) []string {
+	pages, excluded := c.filterPages(pages)
+	report.ExcludedPages = append(report.ExcludedPages, excluded...)
+	pages, disallowed := c.filterRobots(ctx, pages)
+	report.RobotsSkipped = append(report.RobotsSkipped, disallowed...)
+	return pages
+}
+
+// emit passes result to Options.OnResult, if set, leaving out the pages
+// Options.Baseline accepts the problem on.
+func (c *Crawler) emit(result LinkResult) {
+	if c.opts.OnResult == nil {
+		return
+	}
+	if c.opts.Baseline != nil && !c.opts.Baseline.filterResult(&result, time.Now()) {
+		return
+	}
+	c.opts.OnResult(result)
+}
+
+func (c *Crawler) logf(format string, args ...any) {
+	fmt.Fprintf(c.log, format, args...)
+}
+
+func (c *Crawler) logln(args ...any) {
+	fmt.Fprintln(c.log, args...)
+}
@@ -0,0 +1,156 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestCrawler creates a Crawler pointed at a dummy entrypoint for tests
+// that exercise a single stage of the crawl.
+func newTestCrawler(t *testing.T, opts Options) *Crawler {
+	t.Helper()
+	c, err := New("https://example.com/sitemap.xml", opts)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+// newSiteServer serves a sitemap listing two pages that link to a working
+// and a broken URL.
+func newSiteServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<urlset>
+  <url><loc>%[1]s/a</loc></url>
+  <url><loc>%[1]s/b</loc></url>
+</urlset>`, srv.URL)
+		case "/a", "/b":
+			fmt.Fprint(w, `<html><body><a href="/ok">OK</a><a href="/missing">Missing</a></body></html>`)
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// ---- New ----------------------------------------------------------------
+
+func TestNew_InvalidEntrypoint(t *testing.T) {
+	t.Parallel()
+	if _, err := New("not a url", Options{}); err == nil {
+		t.Error("expected error for invalid entrypoint, got nil")
+	}
+}
+
+func TestNew_AppliesDefaults(t *testing.T) {
+	t.Parallel()
+	c := newTestCrawler(t, Options{})
+	if c.opts.Concurrency != DefaultConcurrency {
+		t.Errorf("Concurrency = %d, want %d", c.opts.Concurrency, DefaultConcurrency)
+	}
+	if c.opts.Method != DefaultMethod {
+		t.Errorf("Method = %q, want %q", c.opts.Method, DefaultMethod)
+	}
+	if c.opts.Timeout != DefaultTimeout {
+		t.Errorf("Timeout = %v, want %v", c.opts.Timeout, DefaultTimeout)
+	}
+	if c.opts.UserAgent != DefaultUserAgent {
+		t.Errorf("UserAgent = %q, want %q", c.opts.UserAgent, DefaultUserAgent)
+	}
+}
+
+// ---- Run ----------------------------------------------------------------
+
+func TestRun_ReportsBrokenLinksWithAllOrigins(t *testing.T) {
+	t.Parallel()
+	srv := newSiteServer(t)
+
+	c, err := New(srv.URL+"/sitemap.xml", Options{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(report.Pages) != 2 {
+		t.Errorf("expected 2 pages, got %d", len(report.Pages))
+	}
+	if n := report.Summary().Checked; n != 2 {
+		t.Errorf("expected 2 checked links, got %d", n)
+	}
+	if len(report.Broken) != 1 {
+		t.Fatalf("expected 1 broken link, got %d: %+v", len(report.Broken), report.Broken)
+	}
+	if got := len(report.Broken[0].Origins); got != 2 {
+		t.Errorf("expected broken link to have 2 origins, got %d", got)
+	}
+}
+
+func TestRun_ConfirmDeclined(t *testing.T) {
+	t.Parallel()
+	srv := newSiteServer(t)
+
+	c, err := New(srv.URL+"/sitemap.xml", Options{
+		Timeout: 5 * time.Second,
+		Confirm: func(pages int) bool {
+			if pages != 2 {
+				t.Errorf("Confirm() got %d pages, want 2", pages)
+			}
+			return false
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report, err := c.Run(context.Background())
+	if !errors.Is(err, ErrAborted) {
+		t.Fatalf("Run() error = %v, want ErrAborted", err)
+	}
+	if len(report.Pages) != 0 || len(report.Links) != 0 {
+		t.Errorf("expected no pages scraped, got %d pages and %d links", len(report.Pages), len(report.Links))
+	}
+}
+
+func TestRun_SitemapNotFound(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL+"/sitemap.xml", Options{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if report, err := c.Run(context.Background()); err == nil {
+		t.Errorf("Run() = %d pages, want an error for a sitemap answering 404", len(report.Pages))
+	}
+}
+
+func TestRun_CancelledContext(t *testing.T) {
+	t.Parallel()
+	srv := newSiteServer(t)
+
+	c, err := New(srv.URL+"/sitemap.xml", Options{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+}
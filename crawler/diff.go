@@ -0,0 +1,68 @@
+package crawler
+
+// ReportDiff is how the problems found changed between two reports.
+type ReportDiff struct {
+	// New lists the problems only found in the later report.
+	New []LinkResult `json:"new"`
+	// Fixed lists the problems of the earlier report that are gone from the
+	// later one, either because the link works now or is no longer linked.
+	Fixed []LinkResult `json:"fixed"`
+	// Persisting lists the problems found in both reports, as they are in
+	// the later one.
+	Persisting []LinkResult `json:"persisting"`
+}
+
+// DiffReports compares the problems in the link results of an earlier and a
+// later report, as read by ReadReport. A link whose problem changed, e.g.
+// from a 404 to a request error, counts as persisting. isProblem decides
+// which results are problems; when nil, broken links, request errors and
+// missing anchors are.
+func DiffReports(before, after []LinkResult, isProblem func(LinkResult) bool) *ReportDiff {
+	if isProblem == nil {
+		isProblem = isDefaultProblem
+	}
+	problems := func(results []LinkResult) map[string]bool {
+		keys := make(map[string]bool)
+		for _, r := range results {
+			if isProblem(r) {
+				keys[diffKey(r)] = true
+			}
+		}
+		return keys
+	}
+	earlier, later := problems(before), problems(after)
+
+	d := &ReportDiff{New: []LinkResult{}, Fixed: []LinkResult{}, Persisting: []LinkResult{}}
+	for _, r := range after {
+		switch key := diffKey(r); {
+		case !later[key]:
+		case earlier[key]:
+			d.Persisting = append(d.Persisting, r)
+		default:
+			d.New = append(d.New, r)
+		}
+	}
+	for _, r := range before {
+		if key := diffKey(r); earlier[key] && !later[key] {
+			d.Fixed = append(d.Fixed, r)
+		}
+	}
+	return d
+}
+
+// diffKey identifies the link a result is about. Missing anchors are told
+// apart by their fragment, as every anchor of a page is a link of its own.
+func diffKey(r LinkResult) string {
+	if r.Category == CategoryMissingAnchor {
+		return r.URL + "#" + r.Fragment
+	}
+	return r.URL
+}
+
+func isDefaultProblem(r LinkResult) bool {
+	switch r.Category {
+	case CategoryBroken, CategoryRequestError, CategoryRedirectLoop, CategoryMissingAnchor:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"slices"
+	"testing"
+)
+
+func diffURLs(results []LinkResult) []string {
+	var urls []string
+	for _, r := range results {
+		urls = append(urls, diffKey(r))
+	}
+	return urls
+}
+
+// ---- DiffReports --------------------------------------------------------
+
+func TestDiffReports(t *testing.T) {
+	t.Parallel()
+	before := []LinkResult{
+		{URL: "https://example.com/fixed", Category: CategoryBroken, StatusCode: 404},
+		{URL: "https://example.com/still", Category: CategoryBroken, StatusCode: 404},
+		{URL: "https://example.com/flaky", Category: CategoryBroken, StatusCode: 500},
+		{URL: "https://example.com/docs", Category: CategoryMissingAnchor, Fragment: "install"},
+		{URL: "https://example.com/new", Category: CategoryOK, StatusCode: 200},
+		{URL: "https://example.com/unlinked", Category: CategoryRequestError, Error: "timeout"},
+	}
+	after := []LinkResult{
+		{URL: "https://example.com/fixed", Category: CategoryOK, StatusCode: 200},
+		{URL: "https://example.com/still", Category: CategoryBroken, StatusCode: 404},
+		{URL: "https://example.com/flaky", Category: CategoryRequestError, Error: "timeout"},
+		{URL: "https://example.com/docs", Category: CategoryMissingAnchor, Fragment: "usage"},
+		{URL: "https://example.com/new", Category: CategoryBroken, StatusCode: 410},
+		{URL: "https://example.com/moved", Category: CategoryWarning, StatusCode: 200, Warnings: []string{"Permanent redirect"}},
+	}
+
+	d := DiffReports(before, after, nil)
+	wantNew := []string{"https://example.com/docs#usage", "https://example.com/new"}
+	wantFixed := []string{"https://example.com/fixed", "https://example.com/docs#install", "https://example.com/unlinked"}
+	wantPersisting := []string{"https://example.com/still", "https://example.com/flaky"}
+	if got := diffURLs(d.New); !slices.Equal(got, wantNew) {
+		t.Errorf("New = %v, want %v", got, wantNew)
+	}
+	if got := diffURLs(d.Fixed); !slices.Equal(got, wantFixed) {
+		t.Errorf("Fixed = %v, want %v", got, wantFixed)
+	}
+	if got := diffURLs(d.Persisting); !slices.Equal(got, wantPersisting) {
+		t.Errorf("Persisting = %v, want %v", got, wantPersisting)
+	}
+	// Persisting problems are reported as they are now
+	if d.Persisting[1].Category != CategoryRequestError {
+		t.Errorf("Persisting[1].Category = %q, want %q", d.Persisting[1].Category, CategoryRequestError)
+	}
+}
+
+func TestDiffReports_IsProblem(t *testing.T) {
+	t.Parallel()
+	before := []LinkResult{{URL: "https://example.com/a", Category: CategoryBroken, StatusCode: 500}}
+	after := []LinkResult{
+		{URL: "https://example.com/a", Category: CategoryBroken, StatusCode: 500},
+		{URL: "https://example.com/b", Category: CategoryWarning, Warnings: []string{"Permanent redirect"}},
+	}
+	onlyWarnings := func(r LinkResult) bool { return r.Category == CategoryWarning }
+
+	d := DiffReports(before, after, onlyWarnings)
+	if got, want := diffURLs(d.New), []string{"https://example.com/b"}; !slices.Equal(got, want) {
+		t.Errorf("New = %v, want %v", got, want)
+	}
+	if len(d.Fixed) != 0 || len(d.Persisting) != 0 {
+		t.Errorf("Fixed = %v, Persisting = %v, want none", d.Fixed, d.Persisting)
+	}
+}
@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Discovery describes how the sitemaps for a crawl were found.
+type Discovery string
+
+const (
+	// DiscoveryEntrypoint means the entrypoint itself was the sitemap URL.
+	DiscoveryEntrypoint Discovery = "entrypoint"
+	// DiscoveryRobots means the sitemaps were listed in robots.txt.
+	DiscoveryRobots Discovery = "robots.txt"
+	// DiscoveryFallback means the well-known sitemap locations were probed.
+	DiscoveryFallback Discovery = "fallback"
+	// DiscoverySpider means no sitemap was used; pages were found by
+	// following links from the entrypoint.
+	DiscoverySpider Discovery = "spider"
+)
+
+// fallbackSitemapPaths are probed when robots.txt lists no sitemaps.
+var fallbackSitemapPaths = []string{"/sitemap.xml", "/sitemap_index.xml"}
+
+// isSiteRoot reports whether u points at the root of a site rather than at
+// a specific sitemap document.
+func isSiteRoot(u *url.URL) bool {
+	return (u.Path == "" || u.Path == "/") && u.RawQuery == ""
+}
+
+// discoverSitemaps returns the sitemaps to crawl for entrypoint. A sitemap
+// URL is used as is; for a bare site URL the sitemaps are looked up in
+// robots.txt, falling back to the well-known sitemap locations.
+func (c *Crawler) discoverSitemaps(ctx context.Context, entrypoint string) ([]string, Discovery, error) {
+	base, err := url.Parse(entrypoint)
+	if err != nil {
+		return nil, "", err
+	}
+	if !isSiteRoot(base) {
+		return []string{entrypoint}, DiscoveryEntrypoint, nil
+	}
+
+	if sitemaps := c.getRobots(ctx, base).sitemaps; len(sitemaps) > 0 {
+		return sitemaps, DiscoveryRobots, nil
+	}
+
+	var sitemaps []string
+	for _, path := range fallbackSitemapPaths {
+		candidate := base.ResolveReference(&url.URL{Path: path}).String()
+		res, err := c.getXML(ctx, candidate)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, "", ctx.Err()
+			}
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode >= 200 && res.StatusCode <= 299 {
+			sitemaps = append(sitemaps, candidate)
+		}
+	}
+	if len(sitemaps) == 0 {
+		return nil, "", fmt.Errorf("no sitemap found for %s", entrypoint)
+	}
+	return sitemaps, DiscoveryFallback, nil
+}
+
+// getSitemaps fetches every sitemap and returns the unique pages across all
+// of them. It only fails if none of the sitemaps could be fetched.
+func (c *Crawler) getSitemaps(ctx context.Context, sitemaps []string) ([]Page, error) {
+	var (
+		pages   []Page
+		lastErr error
+		fetched int
+	)
+	seen := make(map[string]bool)
+	for _, sitemap := range sitemaps {
+		result, err := c.getSitemap(ctx, sitemap)
+		if err != nil {
+			c.logln(err)
+			lastErr = err
+			continue
+		}
+		fetched++
+		for _, p := range result {
+			if !seen[p.URL] {
+				seen[p.URL] = true
+				pages = append(pages, p)
+			}
+		}
+	}
+	if fetched == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return pages, nil
+}
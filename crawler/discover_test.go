@@ -0,0 +1,109 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// ---- discoverSitemaps ---------------------------------------------------
+
+func TestDiscoverSitemaps_SitemapURLUsedAsIs(t *testing.T) {
+	t.Parallel()
+	c := newTestCrawler(t, Options{})
+	got, discovery, err := c.discoverSitemaps(context.Background(), "https://example.com/sitemap.xml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if discovery != DiscoveryEntrypoint || !slices.Equal(got, []string{"https://example.com/sitemap.xml"}) {
+		t.Errorf("got %v via %q, want entrypoint itself", got, discovery)
+	}
+}
+
+func TestDiscoverSitemaps_FromRobots(t *testing.T) {
+	t.Parallel()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprintf(w, "User-agent: *\nSitemap: %[1]s/a.xml\nSitemap: %[1]s/b.xml\n", srv.URL)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	c := newTestCrawler(t, Options{Timeout: 5 * time.Second})
+	got, discovery, err := c.discoverSitemaps(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{srv.URL + "/a.xml", srv.URL + "/b.xml"}
+	if discovery != DiscoveryRobots || !slices.Equal(got, want) {
+		t.Errorf("got %v via %q, want %v via robots.txt", got, discovery, want)
+	}
+}
+
+func TestDiscoverSitemaps_Fallback(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow:\n")
+		case "/sitemap_index.xml":
+			fmt.Fprint(w, `<sitemapindex></sitemapindex>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestCrawler(t, Options{Timeout: 5 * time.Second})
+	got, discovery, err := c.discoverSitemaps(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{srv.URL + "/sitemap_index.xml"}
+	if discovery != DiscoveryFallback || !slices.Equal(got, want) {
+		t.Errorf("got %v via %q, want %v via fallback", got, discovery, want)
+	}
+}
+
+func TestDiscoverSitemaps_NothingFound(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	c := newTestCrawler(t, Options{Timeout: 5 * time.Second})
+	if _, _, err := c.discoverSitemaps(context.Background(), srv.URL); err == nil {
+		t.Error("expected error when no sitemap exists, got nil")
+	}
+}
+
+// ---- getSitemaps --------------------------------------------------------
+
+func TestGetSitemaps_MergesAndDeduplicates(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.xml":
+			fmt.Fprint(w, `<urlset><url><loc>https://example.com/1</loc></url><url><loc>https://example.com/2</loc></url></urlset>`)
+		case "/b.xml":
+			fmt.Fprint(w, `<urlset><url><loc>https://example.com/2</loc></url><url><loc>https://example.com/3</loc></url></urlset>`)
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestCrawler(t, Options{Timeout: 5 * time.Second})
+	pages, err := c.getSitemaps(context.Background(), []string{srv.URL + "/a.xml", srv.URL + "/b.xml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"}
+	if got := pageURLs(pages); !slices.Equal(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
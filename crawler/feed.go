@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// rssFeed covers both RSS 2.0 (<rss><channel><item>) and RSS 1.0, where
+// items are direct children of the <rdf:RDF> root.
+type rssFeed struct {
+	Items    []rssItem `xml:"channel>item"`
+	RDFItems []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Link string `xml:"link"`
+}
+
+type atomFeed struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Links []atomLink `xml:"link"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// parseRSS returns the <link> of every item in an RSS feed.
+func parseRSS(data []byte) ([]string, error) {
+	var feed rssFeed
+	if err := unmarshalFeed(data, &feed); err != nil {
+		return nil, err
+	}
+	var locations []string
+	for _, item := range append(feed.Items, feed.RDFItems...) {
+		if link := strings.TrimSpace(item.Link); link != "" {
+			locations = append(locations, link)
+		}
+	}
+	return locations, nil
+}
+
+// parseAtom returns the alternate link of every entry in an Atom feed.
+// Links with any other relation (self, edit, enclosure, ...) do not point
+// at the entry's page and are skipped.
+func parseAtom(data []byte) ([]string, error) {
+	var feed atomFeed
+	if err := unmarshalFeed(data, &feed); err != nil {
+		return nil, err
+	}
+	var locations []string
+	for _, entry := range feed.Entries {
+		for _, link := range entry.Links {
+			if link.Rel != "" && link.Rel != "alternate" {
+				continue
+			}
+			if href := strings.TrimSpace(link.Href); href != "" {
+				locations = append(locations, href)
+				break
+			}
+		}
+	}
+	return locations, nil
+}
+
+// unmarshalFeed decodes a feed leniently, since feeds in the wild often
+// contain HTML entities and other minor XML errors.
+func unmarshalFeed(data []byte, v any) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	return dec.Decode(v)
+}
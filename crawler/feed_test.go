@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"slices"
+	"testing"
+)
+
+// ---- parseRSS -----------------------------------------------------------
+
+func TestParseRSS(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		xml  string
+		want []string
+	}{
+		{
+			name: "RSS 2.0",
+			xml: `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+  <title>Blog &amp; more</title>
+  <link>https://example.com/</link>
+  <item><title>One</title><link>https://example.com/one</link></item>
+  <item><title>Two &mdash; again</title><link> https://example.com/two </link></item>
+  <item><title>No link</title></item>
+</channel></rss>`,
+			want: []string{"https://example.com/one", "https://example.com/two"},
+		},
+		{
+			name: "RSS 1.0",
+			xml: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+  <channel><link>https://example.com/</link></channel>
+  <item><link>https://example.com/one</link></item>
+</rdf:RDF>`,
+			want: []string{"https://example.com/one"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseRSS([]byte(tt.xml))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseRSS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ---- parseAtom ----------------------------------------------------------
+
+func TestParseAtom(t *testing.T) {
+	t.Parallel()
+	feed := `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <link rel="self" href="https://example.com/feed.atom"/>
+  <entry>
+    <link rel="edit" href="https://example.com/edit/1"/>
+    <link rel="alternate" href="https://example.com/one"/>
+  </entry>
+  <entry>
+    <link href="https://example.com/two"/>
+  </entry>
+  <entry>
+    <link rel="enclosure" href="https://example.com/audio.mp3"/>
+  </entry>
+</feed>`
+	got, err := parseAtom([]byte(feed))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"https://example.com/one", "https://example.com/two"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseAtom() = %v, want %v", got, want)
+	}
+}
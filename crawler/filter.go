@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// URLFilter selects URLs by pattern. A URL passes the filter if it matches
+// one of the Include patterns, or there are none, and matches none of the
+// Exclude patterns.
+//
+// Patterns are globs matched against the whole URL, where * matches any run
+// of characters, e.g. "*/wp-admin/*" or "https://partner.example.com/*".
+// Patterns starting with "re:" are regular expressions instead, matching
+// anywhere in the URL unless anchored, e.g. "re:[?&]replytocom=".
+type URLFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// urlFilter is a compiled URLFilter.
+type urlFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// compile compiles every pattern of the filter.
+func (f URLFilter) compile() (*urlFilter, error) {
+	include, err := compilePatterns(f.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compilePatterns(f.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &urlFilter{include: include, exclude: exclude}, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := compilePattern(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// compilePattern turns a glob or "re:" pattern into a regular expression.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		return regexp.Compile(expr)
+	}
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// allows reports whether rawURL passes the filter.
+func (f *urlFilter) allows(rawURL string) bool {
+	if len(f.include) > 0 && !matchesAny(f.include, rawURL) {
+		return false
+	}
+	return !matchesAny(f.exclude, rawURL)
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPages splits pages into those Options.Pages selects for scraping and
+// those it excludes.
+func (c *Crawler) filterPages(pages []string) (kept, excluded []string) {
+	for _, page := range pages {
+		if c.pageFilter.allows(page) {
+			kept = append(kept, page)
+		} else {
+			excluded = append(excluded, page)
+		}
+	}
+	return kept, excluded
+}
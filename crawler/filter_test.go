@@ -0,0 +1,111 @@
+package crawler
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// ---- URLFilter ----------------------------------------------------------
+
+func TestURLFilterAllows(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		filter URLFilter
+		url    string
+		want   bool
+	}{
+		{"empty filter", URLFilter{}, "https://example.com/", true},
+		{"glob exclude", URLFilter{Exclude: []string{"*/wp-admin/*"}}, "https://example.com/wp-admin/edit.php", false},
+		{"glob exclude miss", URLFilter{Exclude: []string{"*/wp-admin/*"}}, "https://example.com/blog/", true},
+		{"glob is anchored", URLFilter{Exclude: []string{"/wp-admin/*"}}, "https://example.com/wp-admin/x", true},
+		{"glob ? is literal", URLFilter{Exclude: []string{"*?replytocom=*"}}, "https://example.com/post?replytocom=12", false},
+		{"glob dots are literal", URLFilter{Exclude: []string{"https://partner.example.com/*"}}, "https://partnerxexample.com/a", true},
+		{"regex exclude", URLFilter{Exclude: []string{"re:[?&]utm_[a-z]+="}}, "https://example.com/a?x=1&utm_source=mail", false},
+		{"include only", URLFilter{Include: []string{"https://example.com/docs/*"}}, "https://example.com/docs/install", true},
+		{"include miss", URLFilter{Include: []string{"https://example.com/docs/*"}}, "https://example.com/blog/", false},
+		{
+			"exclude beats include",
+			URLFilter{Include: []string{"https://example.com/docs/*"}, Exclude: []string{"*/old/*"}},
+			"https://example.com/docs/old/page", false,
+		},
+	}
+	for _, tt := range tests {
+		f, err := tt.filter.compile()
+		if err != nil {
+			t.Fatalf("%s: compile() error = %v", tt.name, err)
+		}
+		if got := f.allows(tt.url); got != tt.want {
+			t.Errorf("%s: allows(%q) = %v, want %v", tt.name, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestNew_InvalidPattern(t *testing.T) {
+	t.Parallel()
+	if _, err := New("https://example.com/", Options{Links: URLFilter{Exclude: []string{"re:("}}}); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+}
+
+// ---- Run with filters ---------------------------------------------------
+
+func TestRun_ExcludesPagesAndLinks(t *testing.T) {
+	t.Parallel()
+	srv := newSiteServer(t)
+
+	c, err := New(srv.URL+"/sitemap.xml", Options{
+		Timeout: 5 * time.Second,
+		Pages:   URLFilter{Exclude: []string{"*/b"}},
+		Links:   URLFilter{Exclude: []string{"re:/missing$"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if want := []string{srv.URL + "/a"}; !slices.Equal(report.Pages, want) {
+		t.Errorf("Pages = %v, want %v", report.Pages, want)
+	}
+	if want := []string{srv.URL + "/b"}; !slices.Equal(report.ExcludedPages, want) {
+		t.Errorf("ExcludedPages = %v, want %v", report.ExcludedPages, want)
+	}
+	if len(report.ExcludedLinks) != 1 || report.ExcludedLinks[0].URL != srv.URL+"/missing" {
+		t.Errorf("ExcludedLinks = %v, want the /missing link", report.ExcludedLinks)
+	}
+	if len(report.Broken) != 0 {
+		t.Errorf("expected the excluded broken link not to be checked, got %v", report.Broken)
+	}
+
+	summary := report.Summary()
+	if summary.ExcludedPages != 1 || summary.ExcludedLinks != 1 {
+		t.Errorf("Summary() = %+v, want 1 excluded page and link", summary)
+	}
+}
+
+func TestSpider_ExcludedPagesAreNotFollowed(t *testing.T) {
+	t.Parallel()
+	srv := newSiteServer(t)
+
+	c, err := New(srv.URL+"/a", Options{
+		Spider:  true,
+		Timeout: 5 * time.Second,
+		Pages:   URLFilter{Include: []string{"*/a", "*/ok"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report := spider(c)
+
+	if want := []string{srv.URL + "/a", srv.URL + "/ok"}; !slices.Equal(report.Pages, want) {
+		t.Errorf("Pages = %v, want %v", report.Pages, want)
+	}
+	if want := []string{srv.URL + "/missing"}; !slices.Equal(report.ExcludedPages, want) {
+		t.Errorf("ExcludedPages = %v, want %v", report.ExcludedPages, want)
+	}
+}
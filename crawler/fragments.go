@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// MissingAnchor is a link to a #fragment that has no matching element in
+// the target page.
+type MissingAnchor struct {
+	URL      string
+	Fragment string
+	Origins  []Origin
+}
+
+// isVerifiableFragment reports whether fragment is expected to match an
+// element in the target document. Empty fragments and #top always scroll
+// to the top of the page, and hash-bang or #/ fragments are client side
+// routes rather than anchors.
+func isVerifiableFragment(fragment string) bool {
+	if fragment == "" || strings.EqualFold(fragment, "top") {
+		return false
+	}
+	return !strings.HasPrefix(fragment, "!") && !strings.HasPrefix(fragment, "/")
+}
+
+// checkFragments fetches every successfully checked page that is linked to
+// with a #fragment, once per page, and returns the fragments that have no
+// matching id or <a name> in it.
+func (c *Crawler) checkFragments(ctx context.Context, results []CrawlResponse) []MissingAnchor {
+	var targets []CrawlResponse
+	for _, result := range results {
+		if result.OK && hasFragments(result.Origins) {
+			targets = append(targets, result)
+		}
+	}
+
+	found := pool(c.opts.Concurrency, feed(ctx, targets), func(result CrawlResponse) ([]MissingAnchor, bool) {
+		anchors, err := c.getAnchors(ctx, result.URL)
+		if err != nil {
+			c.logf("Failed to fetch %s for anchor check: %v\n", result.URL, err)
+			return nil, false
+		}
+		missing := missingAnchors(result, anchors)
+		for _, m := range missing {
+			c.logf("Missing anchor #%s in %s\n", m.Fragment, m.URL)
+		}
+		return missing, true
+	})
+
+	var missing []MissingAnchor
+	for page := range found {
+		missing = append(missing, page...)
+		for _, m := range page {
+			c.emit(missingAnchorResult(m))
+		}
+	}
+	return missing
+}
+
+// hasFragments reports whether any of origins links to a verifiable fragment.
+func hasFragments(origins []Origin) bool {
+	for _, o := range origins {
+		if isVerifiableFragment(o.Fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingAnchors groups the origins of result by fragment and returns the
+// fragments that are not in anchors.
+func missingAnchors(result CrawlResponse, anchors map[string]bool) []MissingAnchor {
+	var missing []MissingAnchor
+	index := make(map[string]int)
+	for _, o := range result.Origins {
+		if !isVerifiableFragment(o.Fragment) || anchors[o.Fragment] {
+			continue
+		}
+		i, ok := index[o.Fragment]
+		if !ok {
+			i = len(missing)
+			index[o.Fragment] = i
+			missing = append(missing, MissingAnchor{URL: result.URL, Fragment: o.Fragment})
+		}
+		missing[i].Origins = append(missing[i].Origins, o)
+	}
+	return missing
+}
+
+// getAnchors fetches a page and returns every fragment it can be scrolled
+// to: the id of any element and the name of legacy <a name> anchors.
+func (c *Crawler) getAnchors(ctx context.Context, pageURL string) (map[string]bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	anchors := make(map[string]bool)
+	doc.Find("[id]").Each(func(_ int, s *goquery.Selection) {
+		anchors[s.AttrOr("id", "")] = true
+	})
+	doc.Find("a[name]").Each(func(_ int, s *goquery.Selection) {
+		anchors[s.AttrOr("name", "")] = true
+	})
+	return anchors, nil
+}
@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ---- isVerifiableFragment -----------------------------------------------
+
+func TestIsVerifiableFragment(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		fragment string
+		want     bool
+	}{
+		{"installation", true},
+		{"", false},
+		{"top", false},
+		{"TOP", false},
+		{"!/route", false},
+		{"/route", false},
+	}
+	for _, tt := range tests {
+		if got := isVerifiableFragment(tt.fragment); got != tt.want {
+			t.Errorf("isVerifiableFragment(%q) = %v, want %v", tt.fragment, got, tt.want)
+		}
+	}
+}
+
+// ---- checkFragments -----------------------------------------------------
+
+// newFragmentServer serves a page linking to anchors on /docs, some of which
+// exist, and to an external page with a fragment.
+func newFragmentServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body>
+				<a href="/docs#installation">Install</a>
+				<a href="/docs#legacy">Legacy</a>
+				<a href="/docs#removed">Removed</a>
+				<a href="/docs#removed">Removed again</a>
+				<a href="/docs#top">Top</a>
+				<a href="#intro">Intro</a>
+				<a href="https://external.example/#nothing">External</a>
+			</body></html>`)
+		case "/docs":
+			fmt.Fprint(w, `<html><body><h2 id="installation">Install</h2><a name="legacy"></a></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetPageLinks_KeepsInternalFragments(t *testing.T) {
+	t.Parallel()
+	srv := newFragmentServer(t)
+
+	c, err := New(srv.URL+"/", Options{CheckFragments: true, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	fragments := make(map[string]bool)
+	for _, link := range c.getPageLinks(context.Background(), srv.URL+"/") {
+		for _, o := range link.Origins {
+			if o.Fragment != "" {
+				fragments[link.URL+"#"+o.Fragment] = true
+			}
+		}
+	}
+
+	for _, want := range []string{srv.URL + "/docs#installation", srv.URL + "/docs#removed", srv.URL + "/#intro"} {
+		if !fragments[want] {
+			t.Errorf("expected fragment %q to be kept, got %v", want, fragments)
+		}
+	}
+	if fragments["https://external.example/#nothing"] {
+		t.Error("expected fragment on external link to be stripped")
+	}
+}
+
+func TestCheckFragments_ReportsMissingAnchors(t *testing.T) {
+	t.Parallel()
+	srv := newFragmentServer(t)
+
+	c, err := New(srv.URL+"/", Options{CheckFragments: true, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	check := c.startChecks(context.Background())
+	check.add(c.getPageLinks(context.Background(), srv.URL+"/"))
+	check.finish(&Report{})
+
+	missing := c.checkFragments(context.Background(), check.fragmentTargets())
+
+	got := make(map[string]int)
+	for _, m := range missing {
+		got[m.URL+"#"+m.Fragment] = len(m.Origins)
+	}
+	want := map[string]int{
+		srv.URL + "/docs#removed": 2,
+		srv.URL + "/#intro":       1,
+	}
+	if len(got) != len(want) {
+		t.Errorf("missing anchors = %v, want %v", got, want)
+	}
+	for k, n := range want {
+		if got[k] != n {
+			t.Errorf("missing anchor %s has %d origins, want %d", k, got[k], n)
+		}
+	}
+}
+
+func TestRun_FragmentsIgnoredByDefault(t *testing.T) {
+	t.Parallel()
+	srv := newFragmentServer(t)
+
+	c, err := New(srv.URL+"/", Options{Spider: true, MaxDepth: 1, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.MissingAnchors) != 0 {
+		t.Errorf("expected no anchor checks without CheckFragments, got %v", report.MissingAnchors)
+	}
+}
@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed report.html.tmpl
+var htmlReportTemplate string
+
+var htmlReport = template.Must(template.New("report").Parse(htmlReportTemplate))
+
+// htmlRow is a single occurrence of a problem link on a page.
+type htmlRow struct {
+	Target string
+	Page   string
+	Text   string
+	Source string
+	Status string
+	// Class is the status class used for filtering: 3xx, 4xx, 5xx, error,
+	// warning or anchor.
+	Class string
+}
+
+// htmlGroup is a set of rows sharing a broken target or a source page.
+type htmlGroup struct {
+	Key  string
+	Rows []htmlRow
+}
+
+type htmlReportData struct {
+	Entrypoint string
+	Start      string
+	Duration   time.Duration
+	Discovery  Discovery
+	Summary    Summary
+	Classes    []string
+	ByTarget   []htmlGroup
+	ByPage     []htmlGroup
+}
+
+// WriteHTMLReport writes the HTML report (see WriteHTML) to filename.
+func WriteHTMLReport(filename string, report *Report) error {
+	return writeReportFile(filename, report, WriteHTML)
+}
+
+// WriteHTML writes a self-contained HTML page listing the same problems as
+// the CSV report, grouped both by broken target and by the page they were
+// found on, with summary totals, status class filters and sortable columns.
+func WriteHTML(out io.Writer, report *Report) error {
+	var rows []htmlRow
+	for _, result := range report.LinkResults() {
+		if result.Category == CategoryOK {
+			continue
+		}
+		class, status := htmlStatus(result)
+		target := result.URL
+		if result.Fragment != "" {
+			target += "#" + result.Fragment
+		}
+		for _, o := range result.Origins {
+			rows = append(rows, htmlRow{
+				Target: target,
+				Page:   o.URL,
+				Text:   o.Text,
+				Source: o.Source,
+				Status: status,
+				Class:  class,
+			})
+		}
+	}
+
+	var classes []string
+	for _, row := range rows {
+		if !slices.Contains(classes, row.Class) {
+			classes = append(classes, row.Class)
+		}
+	}
+	slices.Sort(classes)
+
+	return htmlReport.Execute(out, htmlReportData{
+		Entrypoint: report.Entrypoint,
+		Start:      report.Start.Format(time.RFC1123),
+		Duration:   report.Duration.Round(time.Millisecond),
+		Discovery:  report.Discovery,
+		Summary:    report.Summary(),
+		Classes:    classes,
+		ByTarget:   groupRows(rows, func(r htmlRow) string { return r.Target }),
+		ByPage:     groupRows(rows, func(r htmlRow) string { return r.Page }),
+	})
+}
+
+// htmlStatus returns the filter class and the status text shown for result.
+func htmlStatus(result LinkResult) (string, string) {
+	switch result.Category {
+	case CategoryWarning:
+		return result.Class(), strings.Join(result.Warnings, "; ")
+	case CategoryBroken:
+		return result.Class(), strconv.Itoa(result.StatusCode) + " " + result.Status
+	}
+	return result.Class(), result.Error
+}
+
+// groupRows groups rows by key, keeping the order in which keys first
+// appear.
+func groupRows(rows []htmlRow, key func(htmlRow) string) []htmlGroup {
+	var groups []htmlGroup
+	index := make(map[string]int)
+	for _, row := range rows {
+		k := key(row)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, htmlGroup{Key: k})
+		}
+		groups[i].Rows = append(groups[i].Rows, row)
+	}
+	return groups
+}
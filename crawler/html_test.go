@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// ---- WriteHTML ----------------------------------------------------------
+
+func TestWriteHTML(t *testing.T) {
+	t.Parallel()
+	report := sampleReport()
+	broken := CrawlResponse{URL: "https://example.com/broken", StatusCode: 404, Origins: []Origin{
+		{URL: "https://example.com/a", Text: "<b>Broken</b>", Source: "a[href]"},
+		{URL: "https://example.com/b", Text: "Again", Source: "a[href]"},
+	}}
+	report.Broken = []CrawlResponse{broken}
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := buf.String()
+
+	for _, want := range []string{
+		"Link report for https://example.com/sitemap.xml",
+		"https://example.com/broken",
+		"404 Not Found",
+		"https://example.com/a",
+		"https://example.com/b",
+		`data-filter="4xx"`,
+		"&lt;b&gt;Broken&lt;/b&gt;",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("HTML report missing %q", want)
+		}
+	}
+	for _, external := range []string{"<link ", "<script src"} {
+		if strings.Contains(s, external) {
+			t.Errorf("HTML report references external assets (%q)", external)
+		}
+	}
+}
+
+func TestWriteHTML_NoProblems(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, &Report{Entrypoint: "https://example.com/"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No broken links found.") {
+		t.Error("expected empty report message")
+	}
+}
+
+// ---- groupRows ----------------------------------------------------------
+
+func TestGroupRows_KeepsFirstSeenOrder(t *testing.T) {
+	t.Parallel()
+	rows := []htmlRow{
+		{Target: "x", Page: "p1"},
+		{Target: "y", Page: "p2"},
+		{Target: "x", Page: "p2"},
+	}
+	groups := groupRows(rows, func(r htmlRow) string { return r.Target })
+	if len(groups) != 2 || groups[0].Key != "x" || groups[1].Key != "y" {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	if len(groups[0].Rows) != 2 {
+		t.Errorf("expected 2 rows for x, got %d", len(groups[0].Rows))
+	}
+}
@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// State is what an incremental crawl keeps of a run for the next one: when
+// it started and the links found on every page.
+type State struct {
+	Start time.Time         `json:"start"`
+	Pages map[string][]Link `json:"pages"`
+}
+
+// NewState returns the state of a finished crawl, holding the links of both
+// scraped and unchanged pages. The crawl must have Options.KeepLinks set.
+func NewState(report *Report) *State {
+	s := &State{Start: report.Start, Pages: make(map[string][]Link)}
+	for _, page := range report.Pages {
+		s.Pages[page] = []Link{}
+	}
+	for _, page := range report.Unchanged {
+		s.Pages[page] = []Link{}
+	}
+	// Links only hold each target once, with every page it was found on
+	for _, links := range [][]Link{report.Links, report.ExcludedLinks} {
+		for _, link := range links {
+			for _, o := range link.Origins {
+				if pageLinks, ok := s.Pages[o.URL]; ok {
+					s.Pages[o.URL] = append(pageLinks, Link{URL: link.URL, Origins: []Origin{o}})
+				}
+			}
+		}
+	}
+	return s
+}
+
+// LoadState reads a state file written by State.Save.
+func LoadState(filename string) (*State, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &s, nil
+}
+
+// Save writes the state to filename, replacing the previous state only once
+// the new one is complete.
+func (s *State) Save(filename string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data)
+}
+
+// unchangedSince reports whether a page has not been modified since start
+// according to its sitemap entry. Pages without a lastmod always count as
+// changed. A lastmod of just a date, which parses as midnight UTC, covers
+// the whole day.
+func (p Page) unchangedSince(start time.Time) bool {
+	if p.LastMod.IsZero() {
+		return false
+	}
+	end := p.LastMod
+	if end.Equal(end.Truncate(24 * time.Hour)) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end.Before(start)
+}
+
+// reusePages splits pages into those to scrape and those unchanged since
+// Options.Previous, recording the latter in report.Unchanged. It returns the
+// pages to scrape and the links the previous run found on the unchanged
+// ones.
+func (c *Crawler) reusePages(report *Report, pages []string) ([]string, []Link) {
+	prev := c.opts.Previous
+	entries := make(map[string]Page, len(report.SitemapPages))
+	for _, p := range report.SitemapPages {
+		entries[p.URL] = p
+	}
+
+	var (
+		scrape []string
+		reused []Link
+	)
+	for _, page := range pages {
+		links, known := prev.Pages[page]
+		if !known || !entries[page].unchangedSince(prev.Start) {
+			scrape = append(scrape, page)
+			continue
+		}
+		report.Unchanged = append(report.Unchanged, page)
+		reused = append(reused, links...)
+	}
+	return scrape, reused
+}
@@ -0,0 +1,186 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newIncrementalServer serves a sitemap of /old, last modified long ago,
+// /new, modified now, and /undated without a lastmod. It records which
+// pages were scraped.
+func newIncrementalServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		scraped []string
+	)
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<urlset>
+  <url><loc>%[1]s/old</loc><lastmod>2020-01-01</lastmod></url>
+  <url><loc>%[1]s/new</loc><lastmod>%[2]s</lastmod></url>
+  <url><loc>%[1]s/undated</loc></url>
+</urlset>`, srv.URL, time.Now().UTC().Format(time.RFC3339))
+		case "/old", "/new", "/undated":
+			mu.Lock()
+			scraped = append(scraped, r.URL.Path)
+			mu.Unlock()
+			fmt.Fprintf(w, `<html><body><a href="/ok%s">Link</a></body></html>`, r.URL.Path)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		slices.Sort(scraped)
+		return scraped
+	}
+}
+
+// ---- Page.unchangedSince ------------------------------------------------
+
+func TestPageUnchangedSince(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		lastMod time.Time
+		want    bool
+	}{
+		{time.Time{}, false},
+		{time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC), false},
+		// A bare date may mean any time that day
+		{time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		if got := (Page{LastMod: tt.lastMod}).unchangedSince(start); got != tt.want {
+			t.Errorf("unchangedSince() with lastmod %v = %v, want %v", tt.lastMod, got, tt.want)
+		}
+	}
+}
+
+// ---- State --------------------------------------------------------------
+
+func TestNewState(t *testing.T) {
+	t.Parallel()
+	a := Origin{URL: "https://example.com/a", Text: "x"}
+	b := Origin{URL: "https://example.com/b", Text: "y"}
+	report := &Report{
+		Start:         time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Pages:         []string{"https://example.com/a", "https://example.com/empty"},
+		Unchanged:     []string{"https://example.com/b"},
+		Links:         []Link{{URL: "https://example.org/", Origins: []Origin{a, b}}},
+		ExcludedLinks: []Link{{URL: "https://example.com/admin", Origins: []Origin{a}}},
+	}
+	s := NewState(report)
+
+	if !s.Start.Equal(report.Start) {
+		t.Errorf("Start = %v, want %v", s.Start, report.Start)
+	}
+	want := map[string][]string{
+		"https://example.com/a":     {"https://example.org/", "https://example.com/admin"},
+		"https://example.com/b":     {"https://example.org/"},
+		"https://example.com/empty": {},
+	}
+	if len(s.Pages) != len(want) {
+		t.Fatalf("got %d pages, want %d: %v", len(s.Pages), len(want), s.Pages)
+	}
+	for page, urls := range want {
+		links := s.Pages[page]
+		if got := linkURLs(links); !slices.Equal(got, urls) {
+			t.Errorf("links of %s = %v, want %v", page, got, urls)
+		}
+		for _, l := range links {
+			if len(l.Origins) != 1 || l.Origins[0].URL != page {
+				t.Errorf("link %s of %s has origins %v, want just the page", l.URL, page, l.Origins)
+			}
+		}
+	}
+}
+
+func linkURLs(links []Link) []string {
+	urls := []string{}
+	for _, l := range links {
+		urls = append(urls, l.URL)
+	}
+	return urls
+}
+
+func TestStateSaveLoad(t *testing.T) {
+	t.Parallel()
+	filename := filepath.Join(t.TempDir(), "state.json")
+	s := &State{
+		Start: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Pages: map[string][]Link{"https://example.com/": {{URL: "https://example.org/", Origins: []Origin{{URL: "https://example.com/", Text: "Ext"}}}}},
+	}
+	if err := s.Save(filename); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := LoadState(filename)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if !got.Start.Equal(s.Start) || len(got.Pages) != 1 || got.Pages["https://example.com/"][0].Origins[0].Text != "Ext" {
+		t.Errorf("LoadState() = %+v, want %+v", got, s)
+	}
+	if matches, _ := filepath.Glob(filename + ".*"); len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+// ---- incremental runs ---------------------------------------------------
+
+func TestRun_Incremental(t *testing.T) {
+	t.Parallel()
+	srv, scraped := newIncrementalServer(t)
+	opts := Options{Timeout: 5 * time.Second, Retry: RetryPolicy{Attempts: 1}, KeepLinks: true}
+
+	c, err := New(srv.URL+"/sitemap.xml", opts)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	first, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+	if got := len(first.SitemapPages); got != 3 {
+		t.Fatalf("got %d sitemap pages, want 3", got)
+	}
+
+	opts.Previous = NewState(first)
+	opts.Previous.Start = time.Now().Add(-time.Minute)
+	if c, err = New(srv.URL+"/sitemap.xml", opts); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	second, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+
+	// /old is scraped by the first run only
+	if got, want := scraped(), []string{"/new", "/new", "/old", "/undated", "/undated"}; !slices.Equal(got, want) {
+		t.Errorf("scraped %v, want %v", got, want)
+	}
+	if want := []string{srv.URL + "/old"}; !slices.Equal(second.Unchanged, want) {
+		t.Errorf("Unchanged = %v, want %v", second.Unchanged, want)
+	}
+	// The links of the unchanged page are still checked
+	if got := linkURLs(second.Links); len(got) != 3 || !slices.Contains(got, srv.URL+"/ok/old") {
+		t.Errorf("Links = %v, want the links of all 3 pages", got)
+	}
+	if n := second.Summary().Checked; n != 3 {
+		t.Errorf("got %d results, want 3", n)
+	}
+}
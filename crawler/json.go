@@ -0,0 +1,342 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Category classifies the outcome of checking a link.
+type Category string
+
+const (
+	CategoryOK            Category = "ok"
+	CategoryWarning       Category = "warning"
+	CategoryBroken        Category = "broken"
+	CategoryRequestError  Category = "request_error"
+	CategoryRedirectLoop  Category = "redirect_loop"
+	CategoryMissingAnchor Category = "missing_anchor"
+)
+
+// LinkResult is the machine-readable record of a single checked link, as
+// written to JSON and JSON Lines reports.
+type LinkResult struct {
+	URL        string     `json:"url"`
+	Category   Category   `json:"category"`
+	StatusCode int        `json:"status_code,omitempty"`
+	Status     string     `json:"status,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Fragment   string     `json:"fragment,omitempty"`
+	FinalURL   string     `json:"final_url,omitempty"`
+	Redirects  []Redirect `json:"redirects,omitempty"`
+	Warnings   []string   `json:"warnings,omitempty"`
+	DurationMS float64    `json:"duration_ms,omitempty"`
+	Attempts   int        `json:"attempts,omitempty"`
+	Cached     bool       `json:"cached,omitempty"`
+	Origins    []Origin   `json:"origins"`
+}
+
+// Class returns the status class of the result: "ok", "warning", "error"
+// for links that could not be requested, "anchor" for missing anchors or
+// the HTTP status class such as "4xx" for broken links.
+func (r LinkResult) Class() string {
+	switch r.Category {
+	case CategoryOK:
+		return "ok"
+	case CategoryWarning:
+		return "warning"
+	case CategoryMissingAnchor:
+		return "anchor"
+	case CategoryRequestError, CategoryRedirectLoop:
+		return "error"
+	}
+	return strconv.Itoa(r.StatusCode/100) + "xx"
+}
+
+// Summary counts the outcomes of a crawl.
+type Summary struct {
+	Pages          int `json:"pages"`
+	Links          int `json:"links"`
+	Checked        int `json:"checked"`
+	OK             int `json:"ok"`
+	Broken         int `json:"broken"`
+	Warnings       int `json:"warnings"`
+	RequestErrors  int `json:"request_errors"`
+	MissingAnchors int `json:"missing_anchors"`
+	RobotsSkipped  int `json:"robots_skipped"`
+	ExcludedPages  int `json:"excluded_pages"`
+	ExcludedLinks  int `json:"excluded_links"`
+	Suppressed     int `json:"suppressed"`
+	Cached         int `json:"cached"`
+	Unchanged      int `json:"unchanged"`
+}
+
+// JSONReport is the full machine-readable report of a crawl. Links come
+// first, as they are written while the crawl runs, see JSONWriter.
+type JSONReport struct {
+	Links      []LinkResult `json:"links"`
+	Entrypoint string       `json:"entrypoint"`
+	StartedAt  time.Time    `json:"started_at"`
+	DurationMS float64      `json:"duration_ms"`
+	Discovery  Discovery    `json:"discovery"`
+	Sitemaps   []string     `json:"sitemaps,omitempty"`
+	Summary    Summary      `json:"summary"`
+	// Skipped lists the pages robots.txt did not allow to be scraped.
+	Skipped []string `json:"robots_skipped,omitempty"`
+	// Suppressed lists the problems accepted by the baseline.
+	Suppressed []LinkResult `json:"suppressed,omitempty"`
+}
+
+// Summary counts the outcomes recorded in the report.
+func (r *Report) Summary() Summary {
+	return Summary{
+		Pages:          len(r.Pages),
+		Links:          len(r.Links),
+		Checked:        r.OK + len(r.Broken) + len(r.RequestErrors),
+		OK:             r.OK,
+		Broken:         len(r.Broken),
+		Warnings:       len(r.Warnings),
+		RequestErrors:  len(r.RequestErrors),
+		MissingAnchors: len(r.MissingAnchors),
+		RobotsSkipped:  len(r.RobotsSkipped),
+		ExcludedPages:  len(r.ExcludedPages),
+		ExcludedLinks:  len(r.ExcludedLinks),
+		Suppressed:     len(r.Suppressed),
+		Cached:         r.Cached,
+		Unchanged:      len(r.Unchanged),
+	}
+}
+
+// LinkResults returns a record for every problem in the report: broken
+// links first, then warnings, request errors and finally missing anchors.
+// Links that were OK are only counted, see Options.OnResult.
+func (r *Report) LinkResults() []LinkResult {
+	results := make([]LinkResult, 0, len(r.Broken)+len(r.Warnings)+len(r.RequestErrors)+len(r.MissingAnchors))
+	r.eachLinkResult(func(result LinkResult) error {
+		results = append(results, result)
+		return nil
+	})
+	return results
+}
+
+// eachLinkResult calls fn with the record of every problem, in the order of
+// LinkResults, without building them all at once. It stops at the first
+// error fn returns and returns it.
+func (r *Report) eachLinkResult(fn func(LinkResult) error) error {
+	for _, items := range [][]CrawlResponse{r.Broken, r.Warnings} {
+		for _, item := range items {
+			if err := fn(responseResult(item)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, e := range r.RequestErrors {
+		if err := fn(requestErrorResult(e)); err != nil {
+			return err
+		}
+	}
+	for _, m := range r.MissingAnchors {
+		if err := fn(missingAnchorResult(m)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func responseResult(item CrawlResponse) LinkResult {
+	category := CategoryOK
+	switch {
+	case !item.OK:
+		category = CategoryBroken
+	case len(item.Warnings) > 0:
+		category = CategoryWarning
+	}
+	status := http.StatusText(item.StatusCode)
+	if status == "" {
+		status = "Unknown"
+	}
+	return LinkResult{
+		URL:        item.URL,
+		Category:   category,
+		StatusCode: item.StatusCode,
+		Status:     status,
+		FinalURL:   item.FinalURL,
+		Redirects:  item.Redirects,
+		Warnings:   item.Warnings,
+		DurationMS: durationMS(item.Duration),
+		Attempts:   item.Attempts,
+		Cached:     item.Cached,
+		Origins:    item.Origins,
+	}
+}
+
+func requestErrorResult(e RequestError) LinkResult {
+	category := CategoryRequestError
+	if errors.Is(e.Err, ErrRedirectLoop) {
+		category = CategoryRedirectLoop
+	}
+	return LinkResult{
+		URL:      e.URL,
+		Category: category,
+		Error:    e.Err.Error(),
+		Attempts: e.Attempts,
+		Origins:  e.Origins,
+	}
+}
+
+func missingAnchorResult(m MissingAnchor) LinkResult {
+	return LinkResult{
+		URL:      m.URL,
+		Category: CategoryMissingAnchor,
+		Fragment: m.Fragment,
+		Error:    "Missing anchor #" + m.Fragment,
+		Origins:  m.Origins,
+	}
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// NewJSONReport builds the machine-readable form of report.
+func NewJSONReport(report *Report) JSONReport {
+	return JSONReport{
+		Entrypoint: report.Entrypoint,
+		StartedAt:  report.Start,
+		DurationMS: durationMS(report.Duration),
+		Discovery:  report.Discovery,
+		Sitemaps:   report.Sitemaps,
+		Summary:    report.Summary(),
+		Links:      report.LinkResults(),
+		Skipped:    report.RobotsSkipped,
+		Suppressed: report.Suppressed,
+	}
+}
+
+// WriteJSONReport writes the JSON report (see WriteJSON) to filename.
+func WriteJSONReport(filename string, report *Report) error {
+	return writeReportFile(filename, report, WriteJSON)
+}
+
+// WriteJSON writes report to out as indented JSON, the encoding of
+// NewJSONReport. A Report only keeps its problems, so links that were OK
+// are left out unless they were written to a JSONWriter during the crawl.
+func WriteJSON(out io.Writer, report *Report) error {
+	return NewJSONWriter(out).Close(report)
+}
+
+// JSONWriter writes a JSON report while the crawl runs: results are
+// encoded one at a time as they are written, e.g. from Options.OnResult,
+// and Close adds the rest of the report once it is done.
+type JSONWriter struct {
+	w     *bufio.Writer
+	links int
+	err   error
+}
+
+// NewJSONWriter returns a JSONWriter writing to w.
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	jw := &JSONWriter{w: bufio.NewWriter(w)}
+	_, jw.err = jw.w.WriteString("{\n  \"links\": [")
+	return jw
+}
+
+// Write adds result to the links of the report. After the first error
+// every further write is skipped and the error is returned by Close.
+func (w *JSONWriter) Write(result LinkResult) {
+	if w.err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(result, "    ", "  ")
+	if err != nil {
+		w.err = err
+		return
+	}
+	if w.links == 0 {
+		w.w.WriteString("\n    ")
+	} else {
+		w.w.WriteString(",\n    ")
+	}
+	w.links++
+	_, w.err = w.w.Write(data)
+}
+
+// Close writes the problems of report, which only list every page they
+// were found on once the crawl is done, followed by its other fields, and
+// flushes the report. It does not close the underlying writer.
+func (w *JSONWriter) Close(report *Report) error {
+	report.eachLinkResult(func(result LinkResult) error {
+		w.Write(result)
+		return w.err
+	})
+	if w.err != nil {
+		return w.err
+	}
+	if w.links > 0 {
+		w.w.WriteString("\n  ")
+	}
+	w.w.WriteString("]")
+
+	w.field("entrypoint", report.Entrypoint)
+	w.field("started_at", report.Start)
+	w.field("duration_ms", durationMS(report.Duration))
+	w.field("discovery", report.Discovery)
+	if len(report.Sitemaps) > 0 {
+		w.field("sitemaps", report.Sitemaps)
+	}
+	w.field("summary", report.Summary())
+	if len(report.RobotsSkipped) > 0 {
+		w.field("robots_skipped", report.RobotsSkipped)
+	}
+	if len(report.Suppressed) > 0 {
+		w.field("suppressed", report.Suppressed)
+	}
+	if w.err != nil {
+		return w.err
+	}
+	w.w.WriteString("\n}\n")
+	return w.w.Flush()
+}
+
+// field writes the field name with value after the fields before it.
+func (w *JSONWriter) field(name string, value any) {
+	if w.err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(value, "  ", "  ")
+	if err != nil {
+		w.err = err
+		return
+	}
+	w.w.WriteString(",\n  \"" + name + "\": ")
+	_, w.err = w.w.Write(data)
+}
+
+// JSONLWriter streams link results as JSON Lines, one object per line.
+type JSONLWriter struct {
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONLWriter returns a JSONLWriter writing to w.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{enc: json.NewEncoder(w)}
+}
+
+// Write writes result as a single line. After the first error every
+// further write is skipped and the error is returned by Err.
+func (w *JSONLWriter) Write(result LinkResult) {
+	if w.err != nil {
+		return
+	}
+	w.err = w.enc.Encode(result)
+}
+
+// Err returns the first error encountered while writing, if any.
+func (w *JSONLWriter) Err() error {
+	return w.err
+}
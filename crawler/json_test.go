@@ -0,0 +1,216 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sampleReport returns a report with one result of every category.
+func sampleReport() *Report {
+	warned := CrawlResponse{
+		URL: "https://example.com/old", StatusCode: 200, OK: true,
+		Redirects: []Redirect{{URL: "https://example.com/old", StatusCode: 301, Location: "https://example.com/new"}},
+		FinalURL:  "https://example.com/new",
+		Warnings:  []string{"Permanent redirect, update your link to https://example.com/new"},
+	}
+	broken := CrawlResponse{URL: "https://example.com/broken", StatusCode: 404, Duration: 1500 * time.Microsecond}
+	return &Report{
+		Entrypoint: "https://example.com/sitemap.xml",
+		Discovery:  DiscoveryEntrypoint,
+		Pages:      []string{"https://example.com/"},
+		Links:      make([]Link, 6),
+		OK:         2,
+		Broken:     []CrawlResponse{broken},
+		Warnings:   []CrawlResponse{warned},
+		RequestErrors: []RequestError{
+			{URL: "https://example.com/timeout", Err: errors.New("timeout")},
+			{URL: "https://example.com/loop", Err: ErrRedirectLoop},
+		},
+		MissingAnchors: []MissingAnchor{{URL: "https://example.com/docs", Fragment: "install"}},
+	}
+}
+
+// ---- Summary ------------------------------------------------------------
+
+func TestReportSummary(t *testing.T) {
+	t.Parallel()
+	got := sampleReport().Summary()
+	want := Summary{Pages: 1, Links: 6, Checked: 5, OK: 2, Broken: 1, Warnings: 1, RequestErrors: 2, MissingAnchors: 1}
+	if got != want {
+		t.Errorf("Summary() = %+v, want %+v", got, want)
+	}
+}
+
+// ---- LinkResults --------------------------------------------------------
+
+func TestReportLinkResults_Categories(t *testing.T) {
+	t.Parallel()
+	results := sampleReport().LinkResults()
+
+	want := []Category{CategoryBroken, CategoryWarning, CategoryRequestError, CategoryRedirectLoop, CategoryMissingAnchor}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if r.Category != want[i] {
+			t.Errorf("result %d (%s): category = %q, want %q", i, r.URL, r.Category, want[i])
+		}
+	}
+	if results[0].DurationMS != 1.5 {
+		t.Errorf("DurationMS = %v, want 1.5", results[0].DurationMS)
+	}
+	if results[0].Status != "Not Found" {
+		t.Errorf("Status = %q, want %q", results[0].Status, "Not Found")
+	}
+}
+
+// ---- WriteJSON ----------------------------------------------------------
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, sampleReport()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded JSONReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if decoded.Entrypoint != "https://example.com/sitemap.xml" {
+		t.Errorf("Entrypoint = %q", decoded.Entrypoint)
+	}
+	if decoded.Summary.Broken != 1 || len(decoded.Links) != 5 {
+		t.Errorf("unexpected summary %+v with %d links", decoded.Summary, len(decoded.Links))
+	}
+}
+
+func TestWriteJSON_MatchesJSONReport(t *testing.T) {
+	t.Parallel()
+	full := sampleReport()
+	full.Sitemaps = []string{"https://example.com/sitemap.xml"}
+	full.RobotsSkipped = []string{"https://example.com/private?a=1&b=<2>"}
+	full.Suppressed = []LinkResult{{URL: "https://twitter.com/x", Category: CategoryBroken, StatusCode: 403}}
+	onlySkipped := sampleReport()
+	onlySkipped.RobotsSkipped = []string{"https://example.com/private"}
+
+	for name, report := range map[string]*Report{
+		"empty":        {},
+		"sample":       sampleReport(),
+		"full":         full,
+		"only skipped": onlySkipped,
+	} {
+		var want bytes.Buffer
+		enc := json.NewEncoder(&want)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(NewJSONReport(report)); err != nil {
+			t.Fatal(err)
+		}
+		var got bytes.Buffer
+		if err := WriteJSON(&got, report); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s: WriteJSON() =\n%s\nwant\n%s", name, got.String(), want.String())
+		}
+	}
+}
+
+func TestJSONWriter_StreamsLinksBeforeProblems(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	w := NewJSONWriter(&buf)
+	w.Write(LinkResult{URL: "https://example.com/ok", Category: CategoryOK, StatusCode: 200, Origins: []Origin{}})
+	if err := w.Close(sampleReport()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded JSONReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("report is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(decoded.Links) != 6 || decoded.Links[0].Category != CategoryOK || decoded.Links[1].Category != CategoryBroken {
+		t.Errorf("links = %+v, want the OK link followed by the problems", decoded.Links)
+	}
+	if decoded.Summary.OK != 2 || decoded.Entrypoint != "https://example.com/sitemap.xml" {
+		t.Errorf("unexpected summary %+v of %q", decoded.Summary, decoded.Entrypoint)
+	}
+}
+
+func TestWriteJSONReport_InvalidPath_ReturnsError(t *testing.T) {
+	t.Parallel()
+	if err := WriteJSONReport("/nonexistent/path/report.json", &Report{}); err == nil {
+		t.Error("expected error for invalid path, got nil")
+	}
+}
+
+// ---- JSONLWriter --------------------------------------------------------
+
+func TestJSONLWriter_OneObjectPerLine(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	w := NewJSONLWriter(&buf)
+	for _, r := range sampleReport().LinkResults() {
+		w.Write(r)
+	}
+	if err := w.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		var r LinkResult
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Errorf("line %d is not a JSON object: %v", i, err)
+		}
+	}
+}
+
+func TestRun_OnResultStreamsEveryLink(t *testing.T) {
+	t.Parallel()
+	srv := newSiteServer(t)
+
+	var (
+		mu      sync.Mutex
+		results []LinkResult
+	)
+	c, err := New(srv.URL+"/sitemap.xml", Options{
+		Timeout: 5 * time.Second,
+		OnResult: func(r LinkResult) {
+			mu.Lock()
+			results = append(results, r)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := c.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(results) != 2 {
+		t.Errorf("expected 2 streamed results, got %d: %+v", len(results), results)
+	}
+}
+
+func TestLinkResultClass(t *testing.T) {
+	t.Parallel()
+	want := []string{"4xx", "warning", "error", "error", "anchor"}
+	for i, r := range sampleReport().LinkResults() {
+		if got := r.Class(); got != want[i] {
+			t.Errorf("%s: Class() = %q, want %q", r.URL, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes the JUnit report (see WriteJUnit) to filename.
+func WriteJUnitReport(filename string, report *Report) error {
+	return writeReportFile(filename, report, WriteJUnit)
+}
+
+// WriteJUnit writes report to out as JUnit XML for CI test dashboards.
+// Every page is a test suite and every problem found on it a test case,
+// failing when the link is broken, could not be requested or points at a
+// missing anchor. Warnings pass but are recorded as system output. Links
+// that were OK are not kept in the report, so they have no test cases.
+func WriteJUnit(out io.Writer, report *Report) error {
+	var (
+		suites  []junitTestSuite
+		suiteMS []float64
+		index   = make(map[string]int)
+	)
+	suite := func(page string) int {
+		i, ok := index[page]
+		if !ok {
+			i = len(suites)
+			index[page] = i
+			suites = append(suites, junitTestSuite{Name: page})
+			suiteMS = append(suiteMS, 0)
+		}
+		return i
+	}
+	// Keep suites in crawl order, even for pages without links
+	for _, page := range report.Pages {
+		suite(page)
+	}
+
+	root := junitTestSuites{
+		Name: report.Entrypoint,
+		Time: junitSeconds(durationMS(report.Duration)),
+	}
+	for _, result := range report.LinkResults() {
+		name := result.URL
+		if result.Fragment != "" {
+			name += "#" + result.Fragment
+		}
+		failure := junitFailureFor(result)
+		for _, o := range result.Origins {
+			tc := junitTestCase{
+				Name:      name,
+				Classname: o.URL,
+				Time:      junitSeconds(result.DurationMS),
+				Failure:   failure,
+			}
+			if result.Category == CategoryWarning {
+				tc.SystemOut = strings.Join(result.Warnings, "\n")
+			}
+
+			i := suite(o.URL)
+			suiteMS[i] += result.DurationMS
+			s := &suites[i]
+			s.Cases = append(s.Cases, tc)
+			s.Tests++
+			root.Tests++
+			if failure != nil {
+				s.Failures++
+				root.Failures++
+			}
+		}
+	}
+	for i := range suites {
+		suites[i].Time = junitSeconds(suiteMS[i])
+	}
+	root.Suites = suites
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+// junitFailureFor returns the failure of a test case for result, or nil
+// when the link passes.
+func junitFailureFor(result LinkResult) *junitFailure {
+	switch result.Category {
+	case CategoryOK, CategoryWarning:
+		return nil
+	case CategoryBroken:
+		message := fmt.Sprintf("HTTP %d %s", result.StatusCode, result.Status)
+		return &junitFailure{Message: message, Type: string(result.Category), Text: message + " for " + result.URL}
+	}
+	return &junitFailure{Message: result.Error, Type: string(result.Category), Text: result.Error}
+}
+
+func junitSeconds(ms float64) string {
+	return fmt.Sprintf("%.3f", ms/1000)
+}
@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+// ---- WriteJUnit ---------------------------------------------------------
+
+func TestWriteJUnit(t *testing.T) {
+	t.Parallel()
+	broken := CrawlResponse{URL: "https://example.com/broken", StatusCode: 404, Origins: []Origin{
+		{URL: "https://example.com/a"},
+		{URL: "https://example.com/b"},
+	}}
+	report := &Report{
+		Entrypoint: "https://example.com/sitemap.xml",
+		Pages:      []string{"https://example.com/a", "https://example.com/b", "https://example.com/empty"},
+		OK:         1,
+		Broken:     []CrawlResponse{broken},
+		RequestErrors: []RequestError{
+			{URL: "https://example.com/down", Err: ErrRedirectLoop, Origins: []Origin{{URL: "https://example.com/b"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("report is not valid XML: %v\n%s", err, buf.String())
+	}
+	if got.Tests != 3 || got.Failures != 3 {
+		t.Errorf("totals: tests=%d failures=%d, want 3 and 3", got.Tests, got.Failures)
+	}
+	if len(got.Suites) != 3 {
+		t.Fatalf("expected a suite per page, got %d", len(got.Suites))
+	}
+
+	a := got.Suites[0]
+	if a.Name != "https://example.com/a" || a.Tests != 1 || a.Failures != 1 {
+		t.Errorf("suite a = %s tests=%d failures=%d", a.Name, a.Tests, a.Failures)
+	}
+	if f := a.Cases[0].Failure; f == nil || f.Message != "HTTP 404 Not Found" {
+		t.Errorf("expected 404 failure for broken link, got %+v", f)
+	}
+
+	b := got.Suites[1]
+	if f := b.Cases[1].Failure; f == nil || f.Message != ErrRedirectLoop.Error() || f.Type != string(CategoryRedirectLoop) {
+		t.Errorf("expected redirect loop failure, got %+v", f)
+	}
+
+	if empty := got.Suites[2]; empty.Tests != 0 {
+		t.Errorf("expected empty suite for page without links, got %d tests", empty.Tests)
+	}
+}
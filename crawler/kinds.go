@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Kind classifies the type of resource a link points at, based on the
+// element it was found in.
+type Kind string
+
+const (
+	KindAnchor     Kind = "anchor"
+	KindImage      Kind = "image"
+	KindScript     Kind = "script"
+	KindStylesheet Kind = "stylesheet"
+	KindMedia      Kind = "media"
+	KindFrame      Kind = "frame"
+	KindObject     Kind = "object"
+)
+
+// AllKinds lists every resource kind that can be checked.
+var AllKinds = []Kind{KindAnchor, KindImage, KindScript, KindStylesheet, KindMedia, KindFrame, KindObject}
+
+// linkSource describes an element attribute that holds a URL.
+type linkSource struct {
+	kind     Kind
+	element  string
+	selector string
+	attr     string
+	// srcset attributes hold a comma separated list of image candidates.
+	srcset bool
+}
+
+// linkSources lists every element attribute links are extracted from.
+var linkSources = []linkSource{
+	{kind: KindAnchor, element: "a", selector: "a[href]", attr: "href"},
+	{kind: KindImage, element: "img", selector: "img[src]", attr: "src"},
+	{kind: KindImage, element: "img", selector: "img[srcset]", attr: "srcset", srcset: true},
+	{kind: KindScript, element: "script", selector: "script[src]", attr: "src"},
+	{kind: KindStylesheet, element: "link", selector: `link[rel~="stylesheet"][href]`, attr: "href"},
+	{kind: KindMedia, element: "source", selector: "source[src]", attr: "src"},
+	{kind: KindMedia, element: "source", selector: "source[srcset]", attr: "srcset", srcset: true},
+	{kind: KindMedia, element: "video", selector: "video[src]", attr: "src"},
+	{kind: KindMedia, element: "video", selector: "video[poster]", attr: "poster"},
+	{kind: KindMedia, element: "audio", selector: "audio[src]", attr: "src"},
+	{kind: KindFrame, element: "iframe", selector: "iframe[src]", attr: "src"},
+	{kind: KindObject, element: "object", selector: "object[data]", attr: "data"},
+}
+
+// String returns the element and attribute, e.g. "img[srcset]".
+func (s linkSource) String() string {
+	return s.element + "[" + s.attr + "]"
+}
+
+// ParseKinds parses a comma separated list of resource kinds, such as
+// "anchor,image". The special value "all" selects every kind.
+func ParseKinds(s string) ([]Kind, error) {
+	var kinds []Kind
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			return AllKinds, nil
+		}
+		kind := Kind(name)
+		found := false
+		for _, k := range AllKinds {
+			if k == kind {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown resource kind %q", name)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// parseSrcset returns the URLs of every image candidate in a srcset
+// attribute, e.g. "small.jpg 480w, large.jpg 1080w".
+func parseSrcset(srcset string) []string {
+	var urls []string
+	rest := srcset
+	for {
+		rest = strings.TrimLeftFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == ',' })
+		if rest == "" {
+			return urls
+		}
+
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		candidate := rest[:end]
+		rest = rest[end:]
+
+		// A URL directly followed by a comma has no descriptors
+		if trimmed := strings.TrimRight(candidate, ","); trimmed != candidate {
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, candidate)
+
+		// Skip the descriptors up to the next candidate
+		if i := strings.Index(rest, ","); i >= 0 {
+			rest = rest[i+1:]
+		} else {
+			rest = ""
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"slices"
+	"testing"
+)
+
+// ---- ParseKinds ---------------------------------------------------------
+
+func TestParseKinds(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in      string
+		want    []Kind
+		wantErr bool
+	}{
+		{"anchor", []Kind{KindAnchor}, false},
+		{"anchor, Image,script", []Kind{KindAnchor, KindImage, KindScript}, false},
+		{"all", AllKinds, false},
+		{"", nil, false},
+		{"anchor,video", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseKinds(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseKinds(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseKinds(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// ---- parseSrcset --------------------------------------------------------
+
+func TestParseSrcset(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		srcset string
+		want   []string
+	}{
+		{"single URL", "image.jpg", []string{"image.jpg"}},
+		{"width descriptors", "small.jpg 480w, large.jpg 1080w", []string{"small.jpg", "large.jpg"}},
+		{"density descriptors without spaces", "a.png 1x,b.png 2x", []string{"a.png", "b.png"}},
+		{"URL followed directly by comma", "a.png, b.png", []string{"a.png", "b.png"}},
+		// Commas are valid inside URLs; only trailing commas end a candidate
+		{"comma inside URL", "a.png,b.png", []string{"a.png,b.png"}},
+		{"extra whitespace", "  a.png   1x ,\n b.png  2x  ", []string{"a.png", "b.png"}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := parseSrcset(tt.srcset); !slices.Equal(got, tt.want) {
+				t.Errorf("parseSrcset(%q) = %v, want %v", tt.srcset, got, tt.want)
+			}
+		})
+	}
+}
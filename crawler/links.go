@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// getPageLinks fetches a page and returns all unique HTTP(S) links found in it.
+func (c *Crawler) getPageLinks(ctx context.Context, inputURL string) []Link {
+	parsedBase, err := url.Parse(inputURL)
+	if err != nil {
+		c.logf("Failed to parse URL %s: %v\n", inputURL, err)
+		return nil
+	}
+
+	c.logln("Link scraping:", inputURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, inputURL, nil)
+	if err != nil {
+		c.logf("Failed to create request for %s: %v\n", inputURL, err)
+		return nil
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+
+	// Ask for the page only if it changed since the links were cached
+	var cached cachedPage
+	var haveCached bool
+	if c.opts.Cache != nil {
+		if cached, haveCached = c.opts.Cache.page(inputURL, c.opts.Kinds); haveCached {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.logf("Failed to fetch %s: %v\n", inputURL, err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var links []Link
+	if resp.StatusCode == http.StatusNotModified && haveCached {
+		c.logln("Not modified, reusing cached links:", inputURL)
+		links = cached.Links
+	} else {
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		if err != nil {
+			c.logf("Failed to parse HTML from %s: %v\n", inputURL, err)
+			return nil
+		}
+		links = extractLinks(doc, inputURL, parsedBase, c.opts.Kinds)
+		if c.opts.Cache != nil && resp.StatusCode == http.StatusOK {
+			if err := c.opts.Cache.storePage(inputURL, resp.Header, c.opts.Kinds, links); err != nil {
+				c.logf("Failed to cache links of %s: %v\n", inputURL, err)
+			}
+		}
+	}
+
+	// Fragments are only kept for links into the site itself, and only when
+	// they are going to be verified
+	for i := range links {
+		o := &links[i].Origins[0]
+		if o.Fragment != "" && (!c.opts.CheckFragments || !isAllowedHost(links[i].URL, c.hosts)) {
+			o.Fragment = ""
+		}
+	}
+	return links
+}
+
+// extractLinks returns every HTTP(S) link of the given kinds found in the
+// document of pageURL, resolved against base.
+func extractLinks(doc *goquery.Document, pageURL string, base *url.URL, kinds []Kind) []Link {
+	var links []Link
+	for _, src := range linkSources {
+		if !slices.Contains(kinds, src.kind) {
+			continue
+		}
+		doc.Find(src.selector).Each(func(_ int, s *goquery.Selection) {
+			value, exists := s.Attr(src.attr)
+			if !exists {
+				return
+			}
+
+			var linkText string
+			switch src.kind {
+			case KindAnchor:
+				linkText = strings.TrimSpace(s.Text())
+			case KindImage:
+				linkText = strings.TrimSpace(s.AttrOr("alt", ""))
+			}
+
+			values := []string{value}
+			if src.srcset {
+				values = parseSrcset(value)
+			}
+
+			for _, linkURL := range values {
+				parsedLink, err := url.Parse(strings.TrimSpace(linkURL))
+				if err != nil {
+					continue
+				}
+
+				// Resolve relative URLs against the page base
+				resolved := base.ResolveReference(parsedLink)
+
+				// Skip non-HTTP schemes (mailto:, tel:, javascript:, data:, etc.)
+				if resolved.Scheme != "http" && resolved.Scheme != "https" {
+					continue
+				}
+
+				// The fragment is kept on the origin so it can be verified
+				fragment := normalizeLink(resolved)
+
+				links = append(links, Link{
+					URL: resolved.String(),
+					Origins: []Origin{{
+						URL:      pageURL,
+						Text:     linkText,
+						Kind:     src.kind,
+						Source:   src.String(),
+						Fragment: fragment,
+					}},
+				})
+			}
+		})
+	}
+
+	return links
+}
+
+// normalizeLink strips the fragment from u, as #section links point to the
+// same resource, and gives an empty path the root path, so that
+// https://example.com and https://example.com/ are one link. It returns the
+// fragment removed.
+func normalizeLink(u *url.URL) string {
+	fragment := u.Fragment
+	u.Fragment, u.RawFragment = "", ""
+	if u.Path == "" && u.Opaque == "" {
+		u.Path, u.RawPath = "/", ""
+	}
+	return fragment
+}
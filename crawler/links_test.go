@@ -0,0 +1,273 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ---- getPageLinks -------------------------------------------------------
+
+func TestGetPageLinks_ReturnsAbsoluteAndRelativeLinks(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body>
+			<a href="/about">About</a>
+			<a href="https://external.com/page">External</a>
+		</body></html>`)
+	}))
+	defer srv.Close()
+
+	links := newTestCrawler(t, Options{}).getPageLinks(context.Background(), srv.URL+"/")
+	if len(links) != 2 {
+		t.Fatalf("expected 2 links, got %d: %v", len(links), links)
+	}
+}
+
+func TestGetPageLinks_ResolvesRelativeURLs(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body>
+			<a href="/contact">Absolute path</a>
+			<a href="sub/page">Relative path</a>
+		</body></html>`)
+	}))
+	defer srv.Close()
+
+	links := newTestCrawler(t, Options{}).getPageLinks(context.Background(), srv.URL+"/base/")
+	for _, link := range links {
+		if !strings.HasPrefix(link.URL, "http") {
+			t.Errorf("expected fully resolved URL, got %q", link.URL)
+		}
+	}
+}
+
+func TestGetPageLinks_FiltersNonHTTPSchemes(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body>
+			<a href="mailto:user@example.com">Email</a>
+			<a href="tel:+1234567890">Phone</a>
+			<a href="javascript:void(0)">JS</a>
+			<a href="irc://irc.example.com/channel">IRC</a>
+			<a href="https://example.com/valid">Valid</a>
+		</body></html>`)
+	}))
+	defer srv.Close()
+
+	links := newTestCrawler(t, Options{}).getPageLinks(context.Background(), srv.URL+"/")
+	if len(links) != 1 {
+		t.Errorf("expected 1 link (only https), got %d: %v", len(links), links)
+	}
+	if len(links) > 0 && links[0].URL != "https://example.com/valid" {
+		t.Errorf("unexpected link URL: %q", links[0].URL)
+	}
+}
+
+func TestGetPageLinks_StripsFragments(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body>
+			<a href="/page#section">Section link</a>
+		</body></html>`)
+	}))
+	defer srv.Close()
+
+	links := newTestCrawler(t, Options{}).getPageLinks(context.Background(), srv.URL+"/")
+	if len(links) != 1 {
+		t.Fatalf("expected 1 link, got %d", len(links))
+	}
+	if strings.Contains(links[0].URL, "#") {
+		t.Errorf("expected fragment to be stripped, got %q", links[0].URL)
+	}
+}
+
+func TestGetPageLinks_SetsOriginFields(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><a href="/page">Click here</a></body></html>`)
+	}))
+	defer srv.Close()
+
+	pageURL := srv.URL + "/"
+	links := newTestCrawler(t, Options{}).getPageLinks(context.Background(), pageURL)
+	if len(links) != 1 {
+		t.Fatalf("expected 1 link, got %d", len(links))
+	}
+	want := []Origin{{URL: pageURL, Text: "Click here", Kind: KindAnchor, Source: "a[href]"}}
+	if !slices.Equal(links[0].Origins, want) {
+		t.Errorf("origins = %v, want %v", links[0].Origins, want)
+	}
+}
+
+func TestGetPageLinks_NetworkError_ReturnsNil(t *testing.T) {
+	t.Parallel()
+	// Point at a port that refuses connections.
+	links := newTestCrawler(t, Options{}).getPageLinks(context.Background(), "http://127.0.0.1:1")
+	if links != nil {
+		t.Errorf("expected nil on network error, got %v", links)
+	}
+}
+
+func TestGetPageLinks_EmptyPage_ReturnsNil(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body></body></html>`)
+	}))
+	defer srv.Close()
+
+	links := newTestCrawler(t, Options{}).getPageLinks(context.Background(), srv.URL+"/")
+	if len(links) != 0 {
+		t.Errorf("expected 0 links, got %d", len(links))
+	}
+}
+
+// ---- checks -------------------------------------------------------------
+
+func TestChecks_KeepsOriginsOfProblems(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	for _, keep := range []bool{false, true} {
+		c := newTestCrawler(t, Options{Timeout: 5 * time.Second, KeepLinks: keep})
+		check := c.startChecks(context.Background())
+		check.add([]Link{
+			{URL: srv.URL + "/broken", Origins: []Origin{{URL: "https://example.com/a", Text: "A"}}},
+			{URL: srv.URL + "/other", Origins: []Origin{{URL: "https://example.com/a", Text: "Other"}}},
+		})
+		check.add([]Link{
+			{URL: srv.URL + "/broken", Origins: []Origin{{URL: "https://example.com/b", Text: "B"}}},
+			// Same page and text again — must not produce a duplicate origin.
+			{URL: srv.URL + "/broken", Origins: []Origin{{URL: "https://example.com/a", Text: "A"}}},
+			{URL: srv.URL + "/other", Origins: []Origin{{URL: "https://example.com/b", Text: "Other"}}},
+		})
+		var report Report
+		check.finish(&report)
+
+		if len(report.Links) != 2 || report.OK != 1 || len(report.Broken) != 1 {
+			t.Fatalf("KeepLinks %v: got %d links, %d OK and %d broken, want 2, 1 and 1", keep, len(report.Links), report.OK, len(report.Broken))
+		}
+		want := []Origin{
+			{URL: "https://example.com/a", Text: "A"},
+			{URL: "https://example.com/b", Text: "B"},
+		}
+		if !slices.Equal(report.Broken[0].Origins, want) {
+			t.Errorf("KeepLinks %v: origins = %v, want %v", keep, report.Broken[0].Origins, want)
+		}
+		if n := len(report.Links[1].Origins); keep && n != 2 || !keep && n != 0 {
+			t.Errorf("KeepLinks %v: OK link kept %d origins", keep, n)
+		}
+	}
+}
+
+// ---- extractLinks -------------------------------------------------------
+
+const assetPage = `<html><head>
+	<link rel="stylesheet" href="/style.css">
+	<link rel="icon" href="/favicon.ico">
+	<script src="/app.js"></script>
+	<script>inline()</script>
+</head><body>
+	<a href="/about">About</a>
+	<img src="/logo.png" alt="Logo" srcset="/logo-2x.png 2x, /logo-3x.png 3x">
+	<picture><source srcset="/hero.webp" type="image/webp"></picture>
+	<video src="/clip.mp4" poster="/poster.jpg"></video>
+	<iframe src="https://player.example.com/embed"></iframe>
+	<object data="/doc.pdf"></object>
+	<img src="data:image/png;base64,AAAA">
+</body></html>`
+
+func TestExtractLinks_Kinds(t *testing.T) {
+	t.Parallel()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(assetPage))
+	if err != nil {
+		t.Fatalf("failed to parse page: %v", err)
+	}
+	base, _ := url.Parse("https://example.com/page")
+
+	tests := []struct {
+		name  string
+		kinds []Kind
+		want  map[string]string
+	}{
+		{
+			name:  "anchors only",
+			kinds: []Kind{KindAnchor},
+			want:  map[string]string{"https://example.com/about": "a[href]"},
+		},
+		{
+			name:  "images",
+			kinds: []Kind{KindImage},
+			want: map[string]string{
+				"https://example.com/logo.png":    "img[src]",
+				"https://example.com/logo-2x.png": "img[srcset]",
+				"https://example.com/logo-3x.png": "img[srcset]",
+			},
+		},
+		{
+			name:  "all kinds",
+			kinds: AllKinds,
+			want: map[string]string{
+				"https://example.com/about":        "a[href]",
+				"https://example.com/logo.png":     "img[src]",
+				"https://example.com/logo-2x.png":  "img[srcset]",
+				"https://example.com/logo-3x.png":  "img[srcset]",
+				"https://example.com/app.js":       "script[src]",
+				"https://example.com/style.css":    "link[href]",
+				"https://example.com/hero.webp":    "source[srcset]",
+				"https://example.com/clip.mp4":     "video[src]",
+				"https://example.com/poster.jpg":   "video[poster]",
+				"https://player.example.com/embed": "iframe[src]",
+				"https://example.com/doc.pdf":      "object[data]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			links := extractLinks(doc, base.String(), base, tt.kinds)
+			got := make(map[string]string)
+			for _, link := range links {
+				got[link.URL] = link.Origins[0].Source
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d links, want %d: %v", len(got), len(tt.want), got)
+			}
+			for u, source := range tt.want {
+				if got[u] != source {
+					t.Errorf("link %s: source = %q, want %q", u, got[u], source)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractLinks_ImageAltAsText(t *testing.T) {
+	t.Parallel()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<img src="/a.png" alt=" A picture ">`))
+	if err != nil {
+		t.Fatalf("failed to parse page: %v", err)
+	}
+	base, _ := url.Parse("https://example.com/")
+
+	links := extractLinks(doc, base.String(), base, []Kind{KindImage})
+	if len(links) != 1 {
+		t.Fatalf("expected 1 link, got %d", len(links))
+	}
+	if o := links[0].Origins[0]; o.Text != "A picture" || o.Kind != KindImage {
+		t.Errorf("origin = %+v, want text %q and kind %q", o, "A picture", KindImage)
+	}
+}
@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+)
+
+// A crawl is a pipeline of stages connected by channels:
+//
+//	sitemap → page fetch → link extraction → dedup → check → report
+//
+// Every stage that makes requests runs a fixed pool of Options.Concurrency
+// goroutines, and everything a stage produces is consumed by a single
+// goroutine that merges it into the report and passes results on to
+// Options.OnResult. Links are checked as soon as they are deduplicated,
+// while later pages are still being fetched, and a busy check stage holds
+// back scraping. Of the links checked only the problems are kept with every
+// page they were found on, see checks.
+
+// pageWindow is how many pages, per worker, may be scraped ahead of the
+// next page to merge while it is still being fetched.
+const pageWindow = 4
+
+// feed sends items on the returned channel, which is closed once they run
+// out or ctx is done.
+func feed[T any](ctx context.Context, items []T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for _, item := range items {
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// pool calls work for every item received from in with n goroutines and
+// sends the outcomes on the returned channel, which is closed once in is
+// closed and every call returned. Outcomes for which work returns false,
+// e.g. because ctx was cancelled, are dropped. The caller must receive
+// until the channel is closed.
+func pool[T, R any](n int, in <-chan T, work func(T) (R, bool)) <-chan R {
+	out := make(chan R, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for range n {
+		go func() {
+			defer wg.Done()
+			for item := range in {
+				if result, ok := work(item); ok {
+					out <- result
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// scrapedPage is the outcome of the page fetch and link extraction stages
+// for the page at index i.
+type scrapedPage struct {
+	i     int
+	links []Link
+}
+
+// scrapePages fetches pages and extracts their links with a pool of
+// workers, calling merge with the links of every page in the order of
+// pages. A page that is slow to fetch holds back the workers once they are
+// pageWindow pages per worker ahead of it, so the links waiting to be
+// merged stay bounded. If ctx is cancelled no further pages are fetched,
+// but every page already handed to a worker is merged.
+func (c *Crawler) scrapePages(ctx context.Context, pages []string, merge func(page string, links []Link)) {
+	window := make(chan struct{}, pageWindow*c.opts.Concurrency)
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := range pages {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				<-window
+				return
+			}
+		}
+	}()
+
+	scraped := pool(c.opts.Concurrency, indexes, func(i int) (scrapedPage, bool) {
+		return scrapedPage{i, c.pageLinks(ctx, pages[i])}, true
+	})
+	pending := make(map[int][]Link)
+	next := 0
+	for page := range scraped {
+		pending[page.i] = page.links
+		for links, ok := pending[next]; ok; links, ok = pending[next] {
+			delete(pending, next)
+			merge(pages[next], links)
+			next++
+			<-window
+		}
+	}
+}
+
+// pageLinks returns the links of page, scraping it unless they were
+// recorded before the crawl was resumed.
+func (c *Crawler) pageLinks(ctx context.Context, page string) []Link {
+	if links, ok := c.progress.scrapedLinks(page); ok {
+		return links
+	}
+	links := c.getPageLinks(ctx, page)
+	// A page cut short by cancelling is scraped again on resume
+	if ctx.Err() == nil {
+		c.progress.pageScraped(page, links)
+	}
+	return links
+}
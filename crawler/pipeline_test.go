@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ---- pool ---------------------------------------------------------------
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+	var running, maxRunning atomic.Int32
+	evens := pool(3, feed(context.Background(), []int{1, 2, 3, 4, 5, 6, 7, 8}), func(n int) (int, bool) {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			highest := maxRunning.Load()
+			if now <= highest || maxRunning.CompareAndSwap(highest, now) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return n, n%2 == 0
+	})
+
+	var got []int
+	for n := range evens {
+		got = append(got, n)
+	}
+	slices.Sort(got)
+	if want := []int{2, 4, 6, 8}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if highest := maxRunning.Load(); highest > 3 {
+		t.Errorf("%d workers ran at once, want at most 3", highest)
+	}
+}
+
+func TestFeed_StopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	items := feed(ctx, []int{1, 2, 3})
+	<-items
+	cancel()
+	// The feeder may still hand out the item it was offering
+	n := 0
+	for range items {
+		n++
+	}
+	if n > 1 {
+		t.Errorf("got %d more items after cancelling, want at most 1", n)
+	}
+}
+
+// ---- scrapePages --------------------------------------------------------
+
+// newPagesServer serves pages /0 to /n-1, each linking to /target<i>. The
+// first page waits for release before answering, and the test fails if a
+// page from window on is requested before that.
+func newPagesServer(t *testing.T, release <-chan struct{}, served *atomic.Int32, window int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := strings.TrimPrefix(r.URL.Path, "/")
+		if i == "0" {
+			<-release
+		}
+		if n, _ := strconv.Atoi(i); n >= window {
+			select {
+			case <-release:
+			default:
+				t.Errorf("page %d was requested while page 0 was still being fetched", n)
+			}
+		}
+		served.Add(1)
+		fmt.Fprintf(w, `<a href="/target%s">Target</a>`, i)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestScrapePages_MergesInPageOrder(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	var served atomic.Int32
+	// While the first page hangs, the other worker only gets as far ahead
+	// as the window allows
+	limit := int32(pageWindow*2 - 1)
+	srv := newPagesServer(t, release, &served, pageWindow*2)
+	c := newTestCrawler(t, Options{Concurrency: 2, SiteLimits: HostLimits{Concurrency: 2}})
+
+	var pages []string
+	for i := range 20 {
+		pages = append(pages, srv.URL+"/"+strconv.Itoa(i))
+	}
+	var merged []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.scrapePages(context.Background(), pages, func(page string, links []Link) {
+			if len(links) != 1 || links[0].Origins[0].URL != page {
+				t.Errorf("links of %s = %+v", page, links)
+			}
+			merged = append(merged, page)
+		})
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for served.Load() < limit && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := served.Load(); got != limit {
+		t.Errorf("%d pages were scraped ahead of the first, want %d", got, limit)
+	}
+	close(release)
+	<-done
+
+	if !slices.Equal(merged, pages) {
+		t.Errorf("merged %v, want %v", merged, pages)
+	}
+}
+
+func TestScrapePages_Cancelled(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	close(release)
+	var served atomic.Int32
+	srv := newPagesServer(t, release, &served, 0)
+	c := newTestCrawler(t, Options{Concurrency: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.scrapePages(ctx, []string{srv.URL + "/1", srv.URL + "/2", srv.URL + "/3"}, func(page string, links []Link) {
+		if len(links) > 0 {
+			t.Errorf("got links of %s after cancelling", page)
+		}
+	})
+	if n := served.Load(); n > 0 {
+		t.Errorf("%d pages were requested after cancelling, want none", n)
+	}
+}
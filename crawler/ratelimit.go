@@ -0,0 +1,194 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default per-host concurrency used when HostLimits.Concurrency is left at
+// zero. The crawled site gets fewer parallel requests than third-party hosts
+// since it receives most of them.
+const (
+	DefaultSiteHostConcurrency     = 2
+	DefaultExternalHostConcurrency = 4
+)
+
+// HostLimits throttles the requests sent to a single host.
+type HostLimits struct {
+	// Concurrency caps the number of requests in flight to the host.
+	Concurrency int
+	// RequestsPerSecond limits how often a request to the host may start.
+	// Zero means no limit.
+	RequestsPerSecond float64
+	// Delay is the minimum time between the start of two requests to the
+	// host. Zero means no delay.
+	Delay time.Duration
+}
+
+// interval returns the minimum time between the start of two requests.
+func (l HostLimits) interval() time.Duration {
+	interval := l.Delay
+	if l.RequestsPerSecond > 0 {
+		if perRequest := time.Duration(float64(time.Second) / l.RequestsPerSecond); perRequest > interval {
+			interval = perRequest
+		}
+	}
+	return interval
+}
+
+// hostThrottle enforces HostLimits for one host.
+type hostThrottle struct {
+	sem chan struct{}
+
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// acquire waits until a request to the host may start, or ctx is done.
+// Every successful acquire must be paired with a release.
+func (t *hostThrottle) acquire(ctx context.Context) error {
+	select {
+	case t.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Reserve the next start time, then sleep until it comes
+	t.mu.Lock()
+	if t.interval <= 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	start := t.next
+	if start.Before(now) {
+		start = now
+	}
+	t.next = start.Add(t.interval)
+	t.mu.Unlock()
+
+	if err := sleep(ctx, time.Until(start)); err != nil {
+		t.release()
+		return err
+	}
+	return nil
+}
+
+func (t *hostThrottle) release() {
+	<-t.sem
+}
+
+// throttledTransport is a RoundTripper that applies per-host limits to every
+// request, including each hop of a redirect chain. The request timeout is
+// applied here too, so that time spent waiting for a turn does not count
+// against it.
+type throttledTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+	// site is the set of hosts considered internal to the site.
+	site     map[string]bool
+	siteLim  HostLimits
+	otherLim HostLimits
+
+	mu    sync.Mutex
+	hosts map[string]*hostThrottle
+}
+
+func newThrottledTransport(base http.RoundTripper, timeout time.Duration, site map[string]bool, siteLim, otherLim HostLimits) *throttledTransport {
+	return &throttledTransport{
+		base:     base,
+		timeout:  timeout,
+		site:     site,
+		siteLim:  siteLim,
+		otherLim: otherLim,
+		hosts:    make(map[string]*hostThrottle),
+	}
+}
+
+// throttle returns the throttle for the host of u, creating it on first use.
+func (t *throttledTransport) throttle(u *url.URL) *hostThrottle {
+	host := strings.ToLower(u.Host)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if th, ok := t.hosts[host]; ok {
+		return th
+	}
+	limits := t.otherLim
+	if isAllowedHost(u.String(), t.site) {
+		limits = t.siteLim
+	}
+	th := &hostThrottle{
+		sem:      make(chan struct{}, limits.Concurrency),
+		interval: limits.interval(),
+	}
+	t.hosts[host] = th
+	return th
+}
+
+// setMinInterval makes requests to host start at least d apart, e.g. to
+// honour a Crawl-delay from robots.txt. A longer configured interval is kept.
+func (t *throttledTransport) setMinInterval(host string, d time.Duration) {
+	th := t.throttle(&url.URL{Scheme: "http", Host: host})
+	th.mu.Lock()
+	th.interval = max(th.interval, d)
+	th.mu.Unlock()
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	th := t.throttle(req.URL)
+	if err := th.acquire(req.Context()); err != nil {
+		return nil, err
+	}
+	cancel := func() {}
+	if t.timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), t.timeout)
+		req = req.WithContext(ctx)
+	}
+	release := func() {
+		cancel()
+		th.release()
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// The request stays in flight until its body has been closed
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// CloseIdleConnections closes idle connections of the underlying transport.
+func (t *throttledTransport) CloseIdleConnections() {
+	closeIdleConnections(t.base)
+}
+
+// closeIdleConnections closes the idle connections of rt, if it keeps any.
+func closeIdleConnections(rt http.RoundTripper) {
+	type closeIdler interface{ CloseIdleConnections() }
+	if ci, ok := rt.(closeIdler); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// releaseBody calls release once when the body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,173 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newInflightServer returns a server that records the highest number of
+// requests it handled at once. Every request takes delay to answer.
+func newInflightServer(t *testing.T, delay time.Duration) (*httptest.Server, func() int) {
+	t.Helper()
+	var (
+		mu          sync.Mutex
+		inflight    int
+		maxInflight int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inflight++
+		maxInflight = max(maxInflight, inflight)
+		mu.Unlock()
+
+		time.Sleep(delay)
+
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return maxInflight
+	}
+}
+
+func linksTo(base string, n int) []Link {
+	var links []Link
+	for i := range n {
+		links = append(links, Link{URL: fmt.Sprintf("%s/%d", base, i)})
+	}
+	return links
+}
+
+// ---- HostLimits ---------------------------------------------------------
+
+func TestHostLimitsInterval(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		limits HostLimits
+		want   time.Duration
+	}{
+		{HostLimits{}, 0},
+		{HostLimits{RequestsPerSecond: 4}, 250 * time.Millisecond},
+		{HostLimits{Delay: time.Second}, time.Second},
+		{HostLimits{RequestsPerSecond: 4, Delay: 100 * time.Millisecond}, 250 * time.Millisecond},
+		{HostLimits{RequestsPerSecond: 4, Delay: time.Second}, time.Second},
+	}
+	for _, tt := range tests {
+		if got := tt.limits.interval(); got != tt.want {
+			t.Errorf("%+v.interval() = %v, want %v", tt.limits, got, tt.want)
+		}
+	}
+}
+
+// ---- per-host limits ----------------------------------------------------
+
+func TestCheckURLStatus_HostConcurrency(t *testing.T) {
+	t.Parallel()
+	site, siteMax := newInflightServer(t, 20*time.Millisecond)
+	other, otherMax := newInflightServer(t, 20*time.Millisecond)
+
+	c, err := New(site.URL+"/sitemap.xml", Options{
+		Concurrency:    10,
+		Timeout:        5 * time.Second,
+		SiteLimits:     HostLimits{Concurrency: 1},
+		ExternalLimits: HostLimits{Concurrency: 3},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	links := append(linksTo(site.URL, 6), linksTo(other.URL, 6)...)
+	crawled, _, requestErrors := checkURLStatus(context.Background(), c, links)
+
+	if len(crawled) != len(links) || len(requestErrors) != 0 {
+		t.Fatalf("got %d results and %d errors, want %d results", len(crawled), len(requestErrors), len(links))
+	}
+	if got := siteMax(); got != 1 {
+		t.Errorf("max concurrent requests to site = %d, want 1", got)
+	}
+	if got := otherMax(); got > 3 {
+		t.Errorf("max concurrent requests to external host = %d, want <= 3", got)
+	}
+}
+
+func TestCheckURLStatus_HostRate(t *testing.T) {
+	t.Parallel()
+	site, _ := newInflightServer(t, 0)
+
+	c, err := New(site.URL+"/sitemap.xml", Options{
+		Concurrency: 10,
+		Timeout:     5 * time.Second,
+		SiteLimits:  HostLimits{Concurrency: 4, Delay: 50 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// Five requests to the site start at least 4 delays apart
+	start := time.Now()
+	checkURLStatus(context.Background(), c, linksTo(site.URL, 5))
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("site links took %v, want >= 200ms", elapsed)
+	}
+}
+
+func TestCheckURLStatus_HostRateSparesExternalHosts(t *testing.T) {
+	t.Parallel()
+	site, _ := newInflightServer(t, 0)
+	other, _ := newInflightServer(t, 0)
+
+	// With a delay of an hour, any external request held back by the site's
+	// crawl delay is still waiting when the context ends
+	c, err := New(site.URL+"/sitemap.xml", Options{
+		Concurrency: 10,
+		Timeout:     5 * time.Second,
+		SiteLimits:  HostLimits{Concurrency: 4, Delay: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	crawled, _, requestErrors := checkURLStatus(ctx, c, linksTo(other.URL, 5))
+	if len(crawled) != 5 || len(requestErrors) != 0 {
+		t.Errorf("got %d results and %d errors, want all 5 external links checked", len(crawled), len(requestErrors))
+	}
+}
+
+func TestCheckURLStatus_ThrottleHonoursCancel(t *testing.T) {
+	t.Parallel()
+	site, _ := newInflightServer(t, 0)
+
+	c, err := New(site.URL+"/sitemap.xml", Options{
+		Timeout:    5 * time.Second,
+		SiteLimits: HostLimits{Concurrency: 1, Delay: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	done := make(chan []CrawlResponse)
+	go func() {
+		crawled, _, _ := checkURLStatus(ctx, c, linksTo(site.URL, 3))
+		done <- crawled
+	}()
+	select {
+	case crawled := <-done:
+		if len(crawled) != 1 {
+			t.Errorf("got %d results, want only the first request before the delay", len(crawled))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("checkURLStatus did not return after the context was cancelled")
+	}
+}
@@ -0,0 +1,156 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ReadReportFile reads the link results of a report file, see ReadReport.
+func ReadReportFile(filename string) ([]LinkResult, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	results, err := ReadReport(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return results, nil
+}
+
+// ReadReport reads the link results of a report written by WriteJSON,
+// JSONLWriter or WriteCSV, detecting the format from the content. CSV
+// reports only hold problems, so OK links are never returned for them, and
+// results are rebuilt from the columns available.
+func ReadReport(r io.Reader) ([]LinkResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return readCSVReport(data)
+	}
+
+	if isJSONReport(trimmed) {
+		var report JSONReport
+		if err := json.Unmarshal(trimmed, &report); err != nil {
+			return nil, err
+		}
+		return report.Links, nil
+	}
+	return readJSONLReport(trimmed)
+}
+
+// isJSONReport reports whether data is a single JSONReport object rather
+// than JSON Lines. A JSON Lines report of one line is a single object too,
+// so the report's own keys decide.
+func isJSONReport(data []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false
+	}
+	_, hasLinks := fields["links"]
+	_, hasSummary := fields["summary"]
+	return hasLinks && hasSummary
+}
+
+func readJSONLReport(data []byte) ([]LinkResult, error) {
+	var results []LinkResult
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var result LinkResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		results = append(results, result)
+	}
+	return results, scanner.Err()
+}
+
+// readCSVReport rebuilds link results from the rows of a CSV report, which
+// hold one row per link and page it was found on.
+func readCSVReport(data []byte) ([]LinkResult, error) {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	var results []LinkResult
+	index := make(map[string]int)
+	for _, row := range rows[1:] {
+		if len(row) < 6 {
+			return nil, fmt.Errorf("CSV row has %d columns, want 6", len(row))
+		}
+		result := csvRowResult(row)
+		key := string(result.Category) + " " + result.URL + "#" + result.Fragment
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(results)
+			results = append(results, result)
+			continue
+		}
+		// Further rows add origins, or warnings for the same origins
+		existing := &results[i]
+		origin := result.Origins[0]
+		if !slices.Contains(existing.Origins, origin) {
+			existing.Origins = append(existing.Origins, origin)
+		}
+		for _, w := range result.Warnings {
+			if !slices.Contains(existing.Warnings, w) {
+				existing.Warnings = append(existing.Warnings, w)
+			}
+		}
+	}
+	return results, nil
+}
+
+// csvRowResult turns a single CSV report row into a link result.
+func csvRowResult(row []string) LinkResult {
+	target, status, desc := row[0], row[1], row[2]
+	result := LinkResult{
+		URL:     target,
+		Origins: []Origin{{URL: row[4], Text: row[3], Source: row[5]}},
+	}
+
+	code, err := strconv.Atoi(status)
+	switch {
+	case err != nil && strings.HasPrefix(desc, "Missing anchor #"):
+		result.Category = CategoryMissingAnchor
+		result.URL, result.Fragment, _ = strings.Cut(target, "#")
+		result.Error = desc
+	case err != nil:
+		result.Category = CategoryRequestError
+		if strings.Contains(desc, ErrRedirectLoop.Error()) {
+			result.Category = CategoryRedirectLoop
+		}
+		result.Error = desc
+	case desc == http.StatusText(code) || desc == "Unknown":
+		result.Category = CategoryBroken
+		result.StatusCode = code
+		result.Status = desc
+	default:
+		// Warnings carry the status of the first redirect
+		result.Category = CategoryWarning
+		result.Redirects = []Redirect{{URL: target, StatusCode: code}}
+		result.Warnings = []string{desc}
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sampleReportWithOrigins returns sampleReport with every link found on two
+// pages.
+func sampleReportWithOrigins() *Report {
+	origins := []Origin{
+		{URL: "https://example.com/", Text: "Home link", Source: "a[href]"},
+		{URL: "https://example.com/blog", Text: "Blog link", Source: "a[href]"},
+	}
+	report := sampleReport()
+	report.Broken[0].Origins = origins
+	report.Warnings[0].Origins = origins
+	for i := range report.RequestErrors {
+		report.RequestErrors[i].Origins = origins
+	}
+	report.MissingAnchors[0].Origins = origins
+	return report
+}
+
+func categories(results []LinkResult) []Category {
+	var got []Category
+	for _, r := range results {
+		got = append(got, r.Category)
+	}
+	return got
+}
+
+// ---- ReadReport ---------------------------------------------------------
+
+func TestReadReport_JSON(t *testing.T) {
+	t.Parallel()
+	report := sampleReportWithOrigins()
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, report); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	results, err := ReadReport(&buf)
+	if err != nil {
+		t.Fatalf("ReadReport() error = %v", err)
+	}
+	if want := categories(report.LinkResults()); !slices.Equal(categories(results), want) {
+		t.Errorf("categories = %v, want %v", categories(results), want)
+	}
+}
+
+func TestReadReport_JSONL(t *testing.T) {
+	t.Parallel()
+	report := sampleReportWithOrigins()
+	var buf bytes.Buffer
+	jw := NewJSONLWriter(&buf)
+	for _, r := range report.LinkResults() {
+		jw.Write(r)
+	}
+	results, err := ReadReport(&buf)
+	if err != nil {
+		t.Fatalf("ReadReport() error = %v", err)
+	}
+	if len(results) != len(report.LinkResults()) || results[0].StatusCode != 404 {
+		t.Errorf("got %+v, want every streamed result", results)
+	}
+}
+
+func TestReadReport_JSONLSingleLine(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	NewJSONLWriter(&buf).Write(responseResult(sampleReport().Broken[0]))
+	results, err := ReadReport(&buf)
+	if err != nil {
+		t.Fatalf("ReadReport() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Category != CategoryBroken {
+		t.Errorf("got %+v, want the single broken link", results)
+	}
+}
+
+func TestReadReport_CSV(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, sampleReportWithOrigins()); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	results, err := ReadReport(&buf)
+	if err != nil {
+		t.Fatalf("ReadReport() error = %v", err)
+	}
+
+	want := []Category{CategoryBroken, CategoryRequestError, CategoryRedirectLoop, CategoryWarning, CategoryMissingAnchor}
+	if !slices.Equal(categories(results), want) {
+		t.Fatalf("categories = %v, want %v", categories(results), want)
+	}
+	for _, r := range results {
+		if len(r.Origins) != 2 {
+			t.Errorf("%s: got %d origins, want 2", r.URL, len(r.Origins))
+		}
+	}
+	if results[0].StatusCode != 404 || results[0].Status != "Not Found" {
+		t.Errorf("broken result = %+v, want 404 Not Found", results[0])
+	}
+	if r := results[4]; r.URL != "https://example.com/docs" || r.Fragment != "install" {
+		t.Errorf("missing anchor = %+v, want docs#install", r)
+	}
+}
+
+func TestReadReport_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, data := range []string{
+		"{\"url\": \"https://example.com\"}\n{not json}\n",
+		"Broken URL,HTTP Status Code\nhttps://example.com,404\n",
+	} {
+		if _, err := ReadReport(strings.NewReader(data)); err == nil {
+			t.Errorf("ReadReport(%q): expected an error", data)
+		}
+	}
+}
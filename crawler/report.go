@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// writeReportFile creates filename and writes a report to it with write.
+func writeReportFile(filename string, report *Report, write func(io.Writer, *Report) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(file, report); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteCSVReport writes the CSV report (see WriteCSV) to filename.
+func WriteCSVReport(filename string, report *Report) error {
+	return writeReportFile(filename, report, WriteCSV)
+}
+
+// WriteCSV writes every broken link, request error, warning and missing
+// anchor in report to out as CSV, one row per page the link was found on.
+func WriteCSV(out io.Writer, report *Report) error {
+	w := csv.NewWriter(out)
+
+	if err := w.Write([]string{
+		"Broken URL",
+		"HTTP Status Code",
+		"Status Description",
+		"Link Text",
+		"Page Where Link Was Found",
+		"Link Source",
+	}); err != nil {
+		return err
+	}
+
+	for _, item := range report.Broken {
+		statusDesc := http.StatusText(item.StatusCode)
+		if statusDesc == "" {
+			statusDesc = "Unknown"
+		}
+		// One row per referring page so every occurrence can be fixed
+		for _, o := range item.Origins {
+			if err := w.Write([]string{
+				item.URL,
+				strconv.Itoa(item.StatusCode),
+				statusDesc,
+				o.Text,
+				o.URL,
+				o.Source,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, e := range report.RequestErrors {
+		for _, o := range e.Origins {
+			if err := w.Write([]string{
+				e.URL,
+				"N/A",
+				e.Err.Error(),
+				o.Text,
+				o.URL,
+				o.Source,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, item := range report.Warnings {
+		statusCode := item.StatusCode
+		if len(item.Redirects) > 0 {
+			statusCode = item.Redirects[0].StatusCode
+		}
+		for _, warning := range item.Warnings {
+			for _, o := range item.Origins {
+				if err := w.Write([]string{
+					item.URL,
+					strconv.Itoa(statusCode),
+					warning,
+					o.Text,
+					o.URL,
+					o.Source,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, m := range report.MissingAnchors {
+		for _, o := range m.Origins {
+			if err := w.Write([]string{
+				m.URL + "#" + m.Fragment,
+				"N/A",
+				"Missing anchor #" + m.Fragment,
+				o.Text,
+				o.URL,
+				o.Source,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
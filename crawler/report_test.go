@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// ---- WriteCSVReport -----------------------------------------------------
+
+func TestWriteCSVReport_HeaderAndRows(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir() + "/report.csv"
+
+	urlErrors := []CrawlResponse{
+		{URL: "https://example.com/broken", StatusCode: 404, Origins: []Origin{{URL: "https://example.com/", Text: "Click here", Kind: KindAnchor, Source: "a[href]"}}},
+		{URL: "https://example.com/gone", StatusCode: 410, Origins: []Origin{{URL: "https://example.com/page", Text: "Old link"}}},
+	}
+	reqErrors := []RequestError{
+		{URL: "https://example.com/timeout", Err: fmt.Errorf("connection timeout"), Origins: []Origin{{URL: "https://example.com/", Text: "Timeout link"}}},
+	}
+
+	if err := WriteCSVReport(tmp, &Report{Broken: urlErrors, RequestErrors: reqErrors}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(tmp)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	s := string(content)
+
+	for _, want := range []string{
+		"Broken URL", "HTTP Status Code", "Status Description", "Link Text", "Page Where Link Was Found", "Link Source",
+		"https://example.com/broken", "404", "Not Found", "Click here", "a[href]",
+		"https://example.com/gone", "410", "Gone",
+		"https://example.com/timeout", "N/A", "connection timeout", "Timeout link",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("CSV missing expected value %q", want)
+		}
+	}
+}
+
+func TestWriteCSVReport_OneRowPerOrigin(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir() + "/report.csv"
+
+	urlErrors := []CrawlResponse{
+		{URL: "https://example.com/broken", StatusCode: 404, Origins: []Origin{
+			{URL: "https://example.com/a", Text: "First"},
+			{URL: "https://example.com/b", Text: "Second"},
+		}},
+	}
+
+	if err := WriteCSVReport(tmp, &Report{Broken: urlErrors}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(tmp)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d: %v", len(lines), lines)
+	}
+	for i, want := range []string{"https://example.com/a", "https://example.com/b"} {
+		if !strings.Contains(lines[i+1], want) {
+			t.Errorf("row %d = %q, want it to contain %q", i+1, lines[i+1], want)
+		}
+	}
+}
+
+func TestWriteCSVReport_EmptyErrors_HeaderOnly(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir() + "/empty.csv"
+
+	if err := WriteCSVReport(tmp, &Report{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(tmp)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 1 {
+		t.Errorf("expected 1 line (header only), got %d: %v", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], "Broken URL") {
+		t.Errorf("expected header row, got %q", lines[0])
+	}
+}
+
+func TestWriteCSVReport_InvalidPath_ReturnsError(t *testing.T) {
+	t.Parallel()
+	err := WriteCSVReport("/nonexistent/path/report.csv", &Report{})
+	if err == nil {
+		t.Error("expected error for invalid path, got nil")
+	}
+}
+
+func TestWriteCSVReport_MissingAnchors(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir() + "/report.csv"
+
+	report := &Report{MissingAnchors: []MissingAnchor{
+		{URL: "https://example.com/docs", Fragment: "installation", Origins: []Origin{{URL: "https://example.com/", Text: "Install"}}},
+	}}
+	if err := WriteCSVReport(tmp, report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(tmp)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	s := string(content)
+	for _, want := range []string{"https://example.com/docs#installation", "Missing anchor #installation", "Install"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("CSV missing expected value %q", want)
+		}
+	}
+}
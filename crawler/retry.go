@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default retry settings used when the corresponding RetryPolicy field is
+// left at its zero value.
+const (
+	DefaultRetryAttempts = 3
+	DefaultRetryDelay    = time.Second
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// RetryPolicy configures how links answering 429 Too Many Requests or 503
+// Service Unavailable, or failing with a network error, are retried.
+type RetryPolicy struct {
+	// Attempts is the number of times a link is requested before giving up,
+	// including the first request.
+	Attempts int
+	// Delay is the wait before the first retry. It doubles with every
+	// further retry and is randomised by up to half to spread retries out.
+	Delay time.Duration
+	// MaxDelay caps the wait between two attempts, including waits asked
+	// for through a Retry-After header.
+	MaxDelay time.Duration
+}
+
+// isRetryableStatus reports whether a response with code is worth another
+// attempt later on.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// backoff returns the wait before the given retry, counting from 1. A
+// positive retryAfter, as asked for by the server, is used as is; otherwise
+// the delay grows exponentially with jitter.
+func (p RetryPolicy) backoff(retry int, retryAfter time.Duration) time.Duration {
+	wait := retryAfter
+	if wait <= 0 {
+		wait = p.Delay
+		for i := 1; i < retry && wait < p.MaxDelay; i++ {
+			wait *= 2
+		}
+		// Equal jitter: keep half the delay, randomise the other half
+		if half := wait / 2; half > 0 {
+			wait = half + rand.N(half+1)
+		}
+	}
+	return min(wait, p.MaxDelay)
+}
+
+// parseRetryAfter returns the wait asked for by a Retry-After header value,
+// given either in seconds or as an HTTP date. It returns 0 when the header
+// is missing, invalid or in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
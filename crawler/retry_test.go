@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ---- parseRetryAfter ----------------------------------------------------
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"120", 2 * time.Minute},
+		{" 5 ", 5 * time.Second},
+		{"-3", 0},
+		{"soon", 0},
+		{"Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second},
+		{"Wed, 01 May 2024 11:59:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// ---- RetryPolicy.backoff ------------------------------------------------
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+	p := RetryPolicy{Attempts: 5, Delay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for retry, full := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		5: time.Second,
+	} {
+		for range 20 {
+			got := p.backoff(retry, 0)
+			if got < full/2 || got > full {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", retry, got, full/2, full)
+			}
+		}
+	}
+
+	if got := p.backoff(1, 700*time.Millisecond); got != 700*time.Millisecond {
+		t.Errorf("backoff with Retry-After = %v, want 700ms", got)
+	}
+	if got := p.backoff(1, time.Hour); got != time.Second {
+		t.Errorf("backoff with long Retry-After = %v, want MaxDelay", got)
+	}
+}
+
+// ---- checkURLStatus retries ---------------------------------------------
+
+// newFlakyServer answers with status until it has been asked fails times,
+// then with 200 OK. header, if set, is sent as Retry-After.
+func newFlakyServer(t *testing.T, status, fails int, header string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= fails {
+			if header != "" {
+				w.Header().Set("Retry-After", header)
+			}
+			w.WriteHeader(status)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+var fastRetry = RetryPolicy{Attempts: 3, Delay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+func TestCheckURLStatus_RetriesTooManyRequests(t *testing.T) {
+	t.Parallel()
+	srv, calls := newFlakyServer(t, http.StatusTooManyRequests, 1, "")
+
+	links := []Link{{URL: srv.URL + "/"}}
+	crawled, urlErrors, _ := checkURLStatus(context.Background(), newTestCrawler(t, Options{Timeout: 5 * time.Second, Retry: fastRetry}), links)
+
+	if len(crawled) != 1 || len(urlErrors) != 0 {
+		t.Fatalf("got %d results and %d broken, want 1 working result", len(crawled), len(urlErrors))
+	}
+	if crawled[0].Attempts != 2 || calls.Load() != 2 {
+		t.Errorf("Attempts = %d after %d calls, want 2", crawled[0].Attempts, calls.Load())
+	}
+}
+
+func TestCheckURLStatus_GivesUpAfterAttempts(t *testing.T) {
+	t.Parallel()
+	srv, calls := newFlakyServer(t, http.StatusServiceUnavailable, 10, "")
+
+	links := []Link{{URL: srv.URL + "/"}}
+	_, urlErrors, _ := checkURLStatus(context.Background(), newTestCrawler(t, Options{Timeout: 5 * time.Second, Retry: fastRetry}), links)
+
+	if len(urlErrors) != 1 {
+		t.Fatalf("expected 1 broken link, got %d", len(urlErrors))
+	}
+	if urlErrors[0].StatusCode != http.StatusServiceUnavailable || urlErrors[0].Attempts != 3 {
+		t.Errorf("got status %d after %d attempts, want 503 after 3", urlErrors[0].StatusCode, urlErrors[0].Attempts)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 3", calls.Load())
+	}
+}
+
+func TestCheckURLStatus_NoRetryForOtherStatuses(t *testing.T) {
+	t.Parallel()
+	srv, calls := newFlakyServer(t, http.StatusNotFound, 10, "")
+
+	links := []Link{{URL: srv.URL + "/"}}
+	_, urlErrors, _ := checkURLStatus(context.Background(), newTestCrawler(t, Options{Timeout: 5 * time.Second, Retry: fastRetry}), links)
+
+	if len(urlErrors) != 1 || urlErrors[0].Attempts != 1 || calls.Load() != 1 {
+		t.Errorf("expected a single attempt for 404, got %d calls", calls.Load())
+	}
+}
+
+func TestCheckURLStatus_HonoursRetryAfter(t *testing.T) {
+	t.Parallel()
+	srv, _ := newFlakyServer(t, http.StatusTooManyRequests, 1, "1")
+
+	links := []Link{{URL: srv.URL + "/"}}
+	retry := RetryPolicy{Attempts: 2, Delay: time.Millisecond, MaxDelay: 5 * time.Second}
+	start := time.Now()
+	crawled, _, _ := checkURLStatus(context.Background(), newTestCrawler(t, Options{Timeout: 5 * time.Second, Retry: retry}), links)
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want Retry-After of 1s", elapsed)
+	}
+	if len(crawled) != 1 || !crawled[0].OK {
+		t.Errorf("expected a working result after the retry, got %+v", crawled)
+	}
+}
+
+func TestCheckURLStatus_RetryHonoursCancel(t *testing.T) {
+	t.Parallel()
+	srv, _ := newFlakyServer(t, http.StatusTooManyRequests, 10, "3600")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	retry := RetryPolicy{Attempts: 3, Delay: time.Millisecond, MaxDelay: time.Hour}
+	start := time.Now()
+	checkURLStatus(ctx, newTestCrawler(t, Options{Timeout: 5 * time.Second, Retry: retry}), []Link{{URL: srv.URL + "/"}})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("checkURLStatus took %v after the context was cancelled", elapsed)
+	}
+}
@@ -0,0 +1,260 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsTxt is a parsed robots.txt file.
+type robotsTxt struct {
+	sitemaps []string
+	groups   []robotsGroup
+}
+
+// disallowAll is the robots.txt assumed for a site whose robots.txt answers
+// with a server error or cannot be fetched at all.
+var disallowAll = &robotsTxt{groups: []robotsGroup{{agents: []string{"*"}, rules: []robotsRule{{pattern: "/"}}}}}
+
+// robotsGroup is a set of rules that applies to the listed user agents.
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+	// delay is the Crawl-delay asked for, zero if none.
+	delay time.Duration
+}
+
+// robotsRule is a single Allow or Disallow line.
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// parseRobots parses a robots.txt file following RFC 9309: consecutive
+// User-agent lines start a group, and the Allow, Disallow and Crawl-delay
+// lines after them belong to that group. Unknown lines are ignored.
+func parseRobots(r io.Reader) *robotsTxt {
+	robots := &robotsTxt{}
+	var group *robotsGroup
+	// inAgents is set while reading the User-agent lines heading a group
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "sitemap":
+			if value != "" {
+				robots.sitemaps = append(robots.sitemaps, value)
+			}
+		case "user-agent":
+			if !inAgents {
+				robots.groups = append(robots.groups, robotsGroup{})
+				group = &robots.groups[len(robots.groups)-1]
+				inAgents = true
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			// An empty Disallow allows everything, which is the default
+			if group == nil || value == "" {
+				continue
+			}
+			group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			inAgents = false
+			if group == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				group.delay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+	return robots
+}
+
+// group returns the rules for userAgent: every group naming its product
+// token merged together, or the groups for "*" if none does. Agents match
+// the product token, the user agent up to the first slash, ignoring case.
+func (r *robotsTxt) group(userAgent string) robotsGroup {
+	token, _, _ := strings.Cut(userAgent, "/")
+	token = strings.ToLower(strings.TrimSpace(token))
+	var merged robotsGroup
+	found := false
+	for _, g := range r.groups {
+		if !slices.Contains(g.agents, token) {
+			continue
+		}
+		if !found {
+			merged, found = robotsGroup{}, true
+		}
+		merged.rules = append(merged.rules, g.rules...)
+		merged.delay = max(merged.delay, g.delay)
+	}
+	if found {
+		return merged
+	}
+	for _, g := range r.groups {
+		if slices.Contains(g.agents, "*") {
+			merged.rules = append(merged.rules, g.rules...)
+			merged.delay = max(merged.delay, g.delay)
+		}
+	}
+	return merged
+}
+
+// allowed reports whether the group allows fetching u. The longest matching
+// rule wins, with Allow winning ties; anything not matched is allowed.
+func (g robotsGroup) allowed(u *url.URL) bool {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	allow, longest := true, -1
+	for _, rule := range g.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			allow, longest = rule.allow, n
+		}
+	}
+	return allow
+}
+
+// matchRobotsPattern reports whether path matches a robots.txt path
+// pattern. Patterns match path prefixes; * matches any run of characters and
+// a trailing $ anchors the pattern at the end of the path.
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		// Anchored patterns must end with the last part
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
+
+// robotsEntry is the robots.txt of a site origin, nil until it has been
+// fetched. Its own lock lets fetches for different origins run at once.
+type robotsEntry struct {
+	mu     sync.Mutex
+	robots *robotsTxt
+}
+
+// getRobots returns the robots.txt of the site u belongs to, fetching it on
+// first use. As RFC 9309 asks, a robots.txt answering with a 4xx status
+// allows everything, while one answering with a server error or that cannot
+// be fetched at all disallows everything.
+func (c *Crawler) getRobots(ctx context.Context, u *url.URL) *robotsTxt {
+	origin := u.Scheme + "://" + strings.ToLower(u.Host)
+
+	c.robotsMu.Lock()
+	entry, ok := c.robots[origin]
+	if !ok {
+		entry = &robotsEntry{}
+		c.robots[origin] = entry
+	}
+	c.robotsMu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.robots != nil {
+		return entry.robots
+	}
+
+	robots := &robotsTxt{}
+	robotsURL := origin + "/robots.txt"
+	res, err := c.getXML(ctx, robotsURL)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// Try again next time rather than caching a cancelled fetch
+		return robots
+	case err != nil:
+		c.logf("Failed to fetch %s, not scraping %s: %v\n", robotsURL, origin, err)
+		robots = disallowAll
+	default:
+		switch {
+		case res.StatusCode >= 200 && res.StatusCode <= 299:
+			robots = parseRobots(res.Body)
+		case res.StatusCode >= 500:
+			c.logf("Failed to fetch %s, not scraping %s: %s\n", robotsURL, origin, res.Status)
+			robots = disallowAll
+		}
+		res.Body.Close()
+	}
+
+	entry.robots = robots
+	if !c.opts.IgnoreRobots {
+		if delay := robots.group(c.opts.UserAgent).delay; delay > 0 {
+			c.logf("Using crawl delay of %v for %s\n", delay, u.Host)
+			c.transport.setMinInterval(u.Host, delay)
+		}
+	}
+	return robots
+}
+
+// robotsAllowed reports whether robots.txt allows fetching the page at
+// rawURL under the configured user agent. It always does when
+// Options.IgnoreRobots is set.
+func (c *Crawler) robotsAllowed(ctx context.Context, rawURL string) bool {
+	if c.opts.IgnoreRobots {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return true
+	}
+	return c.getRobots(ctx, u).group(c.opts.UserAgent).allowed(u)
+}
+
+// filterRobots splits pages into those robots.txt allows fetching and those
+// it does not.
+func (c *Crawler) filterRobots(ctx context.Context, pages []string) (allowed, skipped []string) {
+	for _, page := range pages {
+		if c.robotsAllowed(ctx, page) {
+			allowed = append(allowed, page)
+			continue
+		}
+		c.logln("Skipped by robots.txt:", page)
+		skipped = append(skipped, page)
+	}
+	return allowed, skipped
+}
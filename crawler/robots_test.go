@@ -0,0 +1,348 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ---- parseRobots --------------------------------------------------------
+
+func TestParseRobots_Sitemaps(t *testing.T) {
+	t.Parallel()
+	robots := `User-agent: *
+Disallow: /admin/
+Sitemap: https://example.com/sitemap.xml
+sitemap:https://example.com/news.xml # news sitemap
+
+User-agent: Googlebot
+SITEMAP: https://example.com/images.xml
+Sitemap:
+`
+	got := parseRobots(strings.NewReader(robots)).sitemaps
+	want := []string{
+		"https://example.com/sitemap.xml",
+		"https://example.com/news.xml",
+		"https://example.com/images.xml",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseRobots().sitemaps = %v, want %v", got, want)
+	}
+}
+
+const robotsGroups = `# Example robots.txt
+User-agent: *
+Disallow: /private/
+Allow: /private/press/
+Crawl-delay: 2
+
+User-agent: BadBot
+User-agent: Golang Link Crawler
+Disallow: /*.pdf$
+Disallow: /search?q=*&page=
+Crawl-delay: 0.5
+
+user-agent: golang link crawler
+disallow: /drafts
+`
+
+func TestParseRobots_Groups(t *testing.T) {
+	t.Parallel()
+	robots := parseRobots(strings.NewReader(robotsGroups))
+	if len(robots.groups) != 3 {
+		t.Fatalf("got %d groups, want 3", len(robots.groups))
+	}
+	if want := []string{"badbot", "golang link crawler"}; !slices.Equal(robots.groups[1].agents, want) {
+		t.Errorf("agents = %v, want %v", robots.groups[1].agents, want)
+	}
+
+	// Our own groups are merged; the * group does not apply
+	ours := robots.group(DefaultUserAgent)
+	if len(ours.rules) != 3 {
+		t.Errorf("got %d rules for %q, want 3", len(ours.rules), DefaultUserAgent)
+	}
+	if ours.delay != 500*time.Millisecond {
+		t.Errorf("delay = %v, want 500ms", ours.delay)
+	}
+
+	other := robots.group("SomeOtherBot/2.0")
+	if len(other.rules) != 2 || other.delay != 2*time.Second {
+		t.Errorf("fallback group = %+v, want the * group", other)
+	}
+
+	if got := parseRobots(strings.NewReader("Disallow: /\n")).group(DefaultUserAgent); len(got.rules) != 0 {
+		t.Errorf("rules outside any group = %v, want none", got.rules)
+	}
+}
+
+func TestRobotsGroup_MatchesProductToken(t *testing.T) {
+	t.Parallel()
+	robots := parseRobots(strings.NewReader(`User-agent: go
+User-agent: link
+Disallow: /
+
+User-agent: *
+Disallow: /private/
+`))
+	// Agents that merely occur in the user agent do not match
+	if got := robots.group(DefaultUserAgent); len(got.rules) != 1 || got.rules[0].pattern != "/private/" {
+		t.Errorf("rules = %+v, want those of the * group", got.rules)
+	}
+	ours := parseRobots(strings.NewReader("User-agent: GOLANG LINK CRAWLER\nDisallow: /\n")).group(DefaultUserAgent)
+	if len(ours.rules) != 1 {
+		t.Errorf("rules = %+v, want the group naming our product token", ours.rules)
+	}
+}
+
+// ---- robotsGroup.allowed ------------------------------------------------
+
+func TestRobotsAllowed(t *testing.T) {
+	t.Parallel()
+	robots := parseRobots(strings.NewReader(robotsGroups))
+	ours := robots.group(DefaultUserAgent)
+	star := robots.group("*")
+
+	tests := []struct {
+		group robotsGroup
+		path  string
+		want  bool
+	}{
+		{ours, "/", true},
+		{ours, "/guide.pdf", false},
+		{ours, "/guide.pdf?download=1", true},
+		{ours, "/search?q=go&page=2", false},
+		{ours, "/search?q=go", true},
+		{ours, "/drafts/post", false},
+		{ours, "/private/", true},
+		{star, "/private/", false},
+		{star, "/private/press/release", true},
+		{star, "/robots.txt", true},
+		{robotsGroup{rules: []robotsRule{{pattern: "/"}}}, "/robots.txt", true},
+		{robotsGroup{rules: []robotsRule{{pattern: "/page"}, {allow: true, pattern: "/page"}}}, "/page", true},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse("https://example.com" + tt.path)
+		if got := tt.group.allowed(u); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRobotsPattern(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/fish", "/fish.html", true},
+		{"/fish", "/Fish.html", false},
+		{"/fish$", "/fish", true},
+		{"/fish$", "/fish/", false},
+		{"/*.php", "/index.php", true},
+		{"/*.php", "/folder/file.php?x=1", true},
+		{"/*.php$", "/file.php?x=1", false},
+		{"/*.php$", "/a.php/b.php", true},
+		{"/a*b*c", "/axxbyyc", true},
+		{"/a*b*c", "/axxcyyb", false},
+		{"*", "/anything", true},
+	}
+	for _, tt := range tests {
+		if got := matchRobotsPattern(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchRobotsPattern(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+// ---- Run with robots.txt ------------------------------------------------
+
+// newRobotsSiteServer serves a sitemap of two pages, one of which robots.txt
+// disallows for every crawler.
+func newRobotsSiteServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var privateFetches atomic.Int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<urlset>
+  <url><loc>%[1]s/public</loc></url>
+  <url><loc>%[1]s/private</loc></url>
+</urlset>`, srv.URL)
+		case "/private":
+			privateFetches.Add(1)
+			fmt.Fprint(w, `<html><body><a href="/public">Public</a></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body><a href="/private">Private</a></body></html>`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &privateFetches
+}
+
+func TestRun_SkipsPagesDisallowedByRobots(t *testing.T) {
+	t.Parallel()
+	srv, privateFetches := newRobotsSiteServer(t)
+
+	for _, spider := range []bool{false, true} {
+		privateFetches.Store(0)
+		entrypoint := srv.URL + "/sitemap.xml"
+		if spider {
+			entrypoint = srv.URL + "/public"
+		}
+		c, err := New(entrypoint, Options{Spider: spider, Timeout: 5 * time.Second, Method: http.MethodGet})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		report, err := c.Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+
+		if want := []string{srv.URL + "/public"}; !slices.Equal(report.Pages, want) {
+			t.Errorf("spider=%v: Pages = %v, want %v", spider, report.Pages, want)
+		}
+		if want := []string{srv.URL + "/private"}; !slices.Equal(report.RobotsSkipped, want) {
+			t.Errorf("spider=%v: RobotsSkipped = %v, want %v", spider, report.RobotsSkipped, want)
+		}
+		// The page is still checked as a link, just never scraped
+		if got := privateFetches.Load(); got != 1 {
+			t.Errorf("spider=%v: /private fetched %d times, want once as a link check", spider, got)
+		}
+	}
+}
+
+func TestRun_IgnoreRobots(t *testing.T) {
+	t.Parallel()
+	srv, _ := newRobotsSiteServer(t)
+
+	c, err := New(srv.URL+"/sitemap.xml", Options{IgnoreRobots: true, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Pages) != 2 || len(report.RobotsSkipped) != 0 {
+		t.Errorf("Pages = %v, RobotsSkipped = %v, want both pages scraped", report.Pages, report.RobotsSkipped)
+	}
+}
+
+func TestRun_RobotsStatus(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		status  int
+		scraped bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusNotFound, true},
+		{http.StatusForbidden, true},
+		{http.StatusServiceUnavailable, false},
+		{http.StatusInternalServerError, false},
+	}
+	for _, tt := range tests {
+		var srv *httptest.Server
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/robots.txt":
+				w.WriteHeader(tt.status)
+			case "/sitemap.xml":
+				fmt.Fprintf(w, `<urlset><url><loc>%s/page</loc></url></urlset>`, srv.URL)
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		c, err := New(srv.URL+"/sitemap.xml", Options{Timeout: 5 * time.Second, Retry: RetryPolicy{Attempts: 1}})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		report, err := c.Run(context.Background())
+		if err != nil {
+			t.Fatalf("status %d: Run() error = %v", tt.status, err)
+		}
+		if scraped := len(report.Pages) == 1 && len(report.RobotsSkipped) == 0; scraped != tt.scraped {
+			t.Errorf("status %d: Pages = %v, RobotsSkipped = %v, want scraped = %v", tt.status, report.Pages, report.RobotsSkipped, tt.scraped)
+		}
+	}
+}
+
+func TestGetRobots_Unreachable(t *testing.T) {
+	t.Parallel()
+	c := newTestCrawler(t, Options{Timeout: 2 * time.Second})
+	u, _ := url.Parse("http://127.0.0.1:1/page")
+	if c.getRobots(context.Background(), u).group(DefaultUserAgent).allowed(u) {
+		t.Error("an unreachable robots.txt allows scraping, want everything disallowed")
+	}
+}
+
+func TestGetRobots_AppliesCrawlDelay(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nCrawl-delay: 3\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL+"/", Options{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	u, _ := url.Parse(srv.URL + "/page")
+	c.getRobots(context.Background(), u)
+
+	th := c.transport.throttle(u)
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	if th.interval != 3*time.Second {
+		t.Errorf("interval = %v, want the 3s crawl delay", th.interval)
+	}
+}
+
+func TestGetRobots_FetchesOriginsConcurrently(t *testing.T) {
+	t.Parallel()
+	// The robots.txt of slow only answers once that of fast was requested,
+	// which never happens while fetches run one at a time
+	slowRequested, fastRequested := make(chan struct{}), make(chan struct{})
+	var overlapped atomic.Bool
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(slowRequested)
+		select {
+		case <-fastRequested:
+			overlapped.Store(true)
+		case <-time.After(5 * time.Second):
+		}
+		fmt.Fprint(w, "User-agent: *\nDisallow: /slow\n")
+	}))
+	t.Cleanup(slow.Close)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(fastRequested)
+		fmt.Fprint(w, "User-agent: *\n")
+	}))
+	t.Cleanup(fast.Close)
+
+	c, err := New(slow.URL+"/", Options{Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	slowURL, _ := url.Parse(slow.URL + "/slow")
+	fastURL, _ := url.Parse(fast.URL + "/page")
+
+	done := make(chan *robotsTxt)
+	go func() { done <- c.getRobots(context.Background(), slowURL) }()
+	<-slowRequested
+	c.getRobots(context.Background(), fastURL)
+	if robots := <-done; robots.group(DefaultUserAgent).allowed(slowURL) {
+		t.Error("robots.txt of the slow origin was not parsed")
+	}
+	if !overlapped.Load() {
+		t.Error("robots.txt of the second origin was only fetched after the first")
+	}
+}
@@ -0,0 +1,259 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// defaultPriority is the priority of pages whose sitemap entry gives none.
+const defaultPriority = 0.5
+
+// Page is a page listed in a sitemap, together with the metadata the
+// sitemap gives for it. Text sitemaps and feeds only provide the URL.
+type Page struct {
+	URL string
+	// LastMod is when the page was last modified, zero if not given.
+	LastMod time.Time
+	// ChangeFreq is how often the page is expected to change, e.g. "daily".
+	ChangeFreq string
+	// Priority is the priority of the page relative to the rest of the
+	// site, from 0 to 1. It is 0.5 if not given.
+	Priority float64
+}
+
+// pagesOf returns a page without metadata for every URL.
+func pagesOf(urls []string) []Page {
+	var pages []Page
+	for _, u := range urls {
+		pages = append(pages, Page{URL: u, Priority: defaultPriority})
+	}
+	return pages
+}
+
+// pageURLs returns the URL of every page.
+func pageURLs(pages []Page) []string {
+	urls := make([]string, 0, len(pages))
+	for _, p := range pages {
+		urls = append(urls, p.URL)
+	}
+	return urls
+}
+
+func (c *Crawler) getSitemap(ctx context.Context, entrypoint string) ([]Page, error) {
+	res, err := c.getXML(ctx, entrypoint)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to fetch sitemap %s: %s", entrypoint, res.Status)
+	}
+
+	body, err := sitemapBody(res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress sitemap %s: %w", entrypoint, err)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sitemap %s: %w", entrypoint, err)
+	}
+
+	var urls []string
+	switch detectSitemapFormat(data) {
+	case formatText:
+		urls = parseTextSitemap(data)
+	case formatRSS:
+		urls, err = parseRSS(data)
+	case formatAtom:
+		urls, err = parseAtom(data)
+	default:
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sitemap XML: %w", err)
+		}
+		return c.parseSitemap(ctx, *doc), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed %s: %w", entrypoint, err)
+	}
+	if len(urls) == 0 {
+		c.logln("Empty result")
+	}
+	return pagesOf(urls), nil
+}
+
+func (c *Crawler) getXML(ctx context.Context, entrypoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, entrypoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	return c.client.Do(req)
+}
+
+// gzipMagic is the two byte header every gzip stream starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// sitemapBody returns a reader over the sitemap in res, transparently
+// decompressing gzipped sitemaps (sitemap.xml.gz). Detection relies on the
+// gzip magic bytes rather than the .gz extension or a gzip Content-Type:
+// servers commonly send .xml.gz files with Content-Encoding: gzip, in which
+// case the transport has already decoded the body and the hints are stale.
+func sitemapBody(res *http.Response) (io.Reader, error) {
+	br := bufio.NewReader(res.Body)
+	magic, _ := br.Peek(len(gzipMagic))
+	if !bytes.Equal(magic, gzipMagic) {
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
+
+// sitemapFormat is the kind of document a page source was detected as.
+type sitemapFormat int
+
+const (
+	formatXML sitemapFormat = iota
+	formatText
+	formatRSS
+	formatAtom
+)
+
+// detectSitemapFormat tells XML sitemaps apart from plain-text sitemaps and
+// RSS or Atom feeds. Anything that does not start with markup is treated as
+// a text sitemap; markup is classified by its root element.
+func detectSitemapFormat(data []byte) sitemapFormat {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(trimmed) > 0 && trimmed[0] != '<' {
+		return formatText
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(trimmed))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return formatXML
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "rss", "RDF":
+				return formatRSS
+			case "feed":
+				return formatAtom
+			}
+			return formatXML
+		}
+	}
+}
+
+// parseTextSitemap returns every absolute HTTP(S) URL in a plain-text
+// sitemap, which lists one URL per line.
+func parseTextSitemap(data []byte) []string {
+	var locations []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		u, err := url.Parse(line)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			continue
+		}
+		locations = append(locations, line)
+	}
+	return locations
+}
+
+// parseURLSet extracts all <loc> values from a sitemap document, together
+// with the <lastmod>, <changefreq> and <priority> of their entries.
+func parseURLSet(doc goquery.Document) []Page {
+	var pages []Page
+	doc.Find("loc").Each(func(_ int, s *goquery.Selection) {
+		loc := strings.TrimSpace(s.Text())
+		if loc == "" {
+			return
+		}
+		entry := s.Parent()
+		field := func(name string) string {
+			return strings.TrimSpace(entry.ChildrenFiltered(name).First().Text())
+		}
+		page := Page{
+			URL:        loc,
+			LastMod:    parseLastMod(field("lastmod")),
+			ChangeFreq: strings.ToLower(field("changefreq")),
+			Priority:   defaultPriority,
+		}
+		if p, err := strconv.ParseFloat(field("priority"), 64); err == nil && p >= 0 && p <= 1 {
+			page.Priority = p
+		}
+		pages = append(pages, page)
+	})
+	return pages
+}
+
+// lastModLayouts are the W3C Datetime formats allowed in <lastmod>.
+// Fractional seconds are accepted by the layouts with seconds.
+var lastModLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// parseLastMod parses a <lastmod> value, returning the zero time if it is
+// empty or invalid.
+func parseLastMod(value string) time.Time {
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *Crawler) parseSitemap(ctx context.Context, doc goquery.Document) []Page {
+	if len(doc.Find("sitemap").Nodes) > 0 {
+		// Sitemap index: fetch each child sitemap concurrently
+		sitemapURLs := pageURLs(parseURLSet(doc))
+		children := pool(c.opts.Concurrency, feed(ctx, sitemapURLs), func(ep string) ([]Page, bool) {
+			result, err := c.getSitemap(ctx, ep)
+			if err != nil {
+				c.logln(err)
+				return nil, false
+			}
+			return result, true
+		})
+		var pages []Page
+		for result := range children {
+			pages = append(pages, result...)
+		}
+
+		// Deduplicate across child sitemaps
+		seen := make(map[string]bool)
+		deduped := make([]Page, 0, len(pages))
+		for _, p := range pages {
+			if !seen[p.URL] {
+				seen[p.URL] = true
+				deduped = append(deduped, p)
+			}
+		}
+		return deduped
+	} else if len(doc.Find("url").Nodes) > 0 {
+		return parseURLSet(doc)
+	}
+
+	c.logln("Empty result")
+	return nil
+}
@@ -0,0 +1,468 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// makeDoc creates a goquery.Document from an XML/HTML string for use in tests.
+func makeDoc(t *testing.T, xml string) goquery.Document {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(xml))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+	return *doc
+}
+
+// ---- parseURLSet --------------------------------------------------------
+
+func TestParseURLSet(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		xml      string
+		wantLocs []string
+	}{
+		{
+			name: "standard urlset with two entries",
+			xml: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset>
+  <url><loc>https://example.com/</loc></url>
+  <url><loc>https://example.com/about</loc></url>
+</urlset>`,
+			wantLocs: []string{"https://example.com/", "https://example.com/about"},
+		},
+		{
+			name:     "empty urlset",
+			xml:      `<urlset></urlset>`,
+			wantLocs: nil,
+		},
+		{
+			name:     "loc with surrounding whitespace is trimmed",
+			xml:      `<urlset><url><loc>  https://example.com/page  </loc></url></urlset>`,
+			wantLocs: []string{"https://example.com/page"},
+		},
+		{
+			name:     "empty loc element is skipped",
+			xml:      `<urlset><url><loc></loc></url><url><loc>https://example.com/</loc></url></urlset>`,
+			wantLocs: []string{"https://example.com/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			doc := makeDoc(t, tt.xml)
+			got := parseURLSet(doc)
+			if len(got) != len(tt.wantLocs) {
+				t.Fatalf("got %d locs, want %d\n  got:  %v\n  want: %v", len(got), len(tt.wantLocs), got, tt.wantLocs)
+			}
+			for i, page := range got {
+				if page.URL != tt.wantLocs[i] {
+					t.Errorf("loc[%d] = %q, want %q", i, page.URL, tt.wantLocs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseURLSet_Metadata(t *testing.T) {
+	t.Parallel()
+	doc := makeDoc(t, `<urlset>
+  <url>
+    <loc>https://example.com/</loc>
+    <lastmod>2024-05-01T10:30:00+02:00</lastmod>
+    <changefreq>Daily</changefreq>
+    <priority>0.8</priority>
+  </url>
+  <url><loc>https://example.com/about</loc><lastmod>2024-04-01</lastmod><priority>2</priority></url>
+  <url><loc>https://example.com/contact</loc><lastmod>yesterday</lastmod></url>
+</urlset>`)
+	want := []Page{
+		{URL: "https://example.com/", LastMod: time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC), ChangeFreq: "daily", Priority: 0.8},
+		{URL: "https://example.com/about", LastMod: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Priority: 0.5},
+		{URL: "https://example.com/contact", Priority: 0.5},
+	}
+	got := parseURLSet(doc)
+	if len(got) != len(want) {
+		t.Fatalf("got %d pages, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].URL != want[i].URL || !got[i].LastMod.Equal(want[i].LastMod) ||
+			got[i].ChangeFreq != want[i].ChangeFreq || got[i].Priority != want[i].Priority {
+			t.Errorf("page %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseLastMod(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-05-01T10:30:15.5Z", time.Date(2024, 5, 1, 10, 30, 15, 5e8, time.UTC)},
+		{"2024-05-01T10:30+01:00", time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)},
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-05", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"", time.Time{}},
+		{"01/05/2024", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := parseLastMod(tt.value); !got.Equal(tt.want) {
+			t.Errorf("parseLastMod(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// ---- parseSitemap -------------------------------------------------------
+
+func TestParseSitemap_URLSet(t *testing.T) {
+	t.Parallel()
+	doc := makeDoc(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset>
+  <url><loc>https://example.com/</loc></url>
+  <url><loc>https://example.com/contact</loc></url>
+</urlset>`)
+	pages := newTestCrawler(t, Options{Concurrency: 5, Timeout: 5 * time.Second}).parseSitemap(context.Background(), doc)
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages, got %d: %v", len(pages), pages)
+	}
+}
+
+func TestParseSitemap_Empty(t *testing.T) {
+	t.Parallel()
+	doc := makeDoc(t, `<root></root>`)
+	pages := newTestCrawler(t, Options{Concurrency: 5, Timeout: 5 * time.Second}).parseSitemap(context.Background(), doc)
+	if len(pages) != 0 {
+		t.Errorf("expected empty result, got %v", pages)
+	}
+}
+
+func TestParseSitemap_SitemapIndex_FetchesChildSitemaps(t *testing.T) {
+	t.Parallel()
+	child1 := `<?xml version="1.0" encoding="UTF-8"?><urlset><url><loc>https://example.com/page1</loc></url></urlset>`
+	child2 := `<?xml version="1.0" encoding="UTF-8"?><urlset><url><loc>https://example.com/page2</loc></url></urlset>`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap1.xml":
+			fmt.Fprint(w, child1)
+		case "/sitemap2.xml":
+			fmt.Fprint(w, child2)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	indexXML := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex>
+  <sitemap><loc>%s/sitemap1.xml</loc></sitemap>
+  <sitemap><loc>%s/sitemap2.xml</loc></sitemap>
+</sitemapindex>`, srv.URL, srv.URL)
+
+	doc := makeDoc(t, indexXML)
+	pages := newTestCrawler(t, Options{Concurrency: 5, Timeout: 5 * time.Second}).parseSitemap(context.Background(), doc)
+
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages from index, got %d: %v", len(pages), pages)
+	}
+}
+
+func TestParseSitemap_SitemapIndex_DeduplicatesPages(t *testing.T) {
+	t.Parallel()
+	// Both child sitemaps return the same URL — only one should survive.
+	child := `<?xml version="1.0" encoding="UTF-8"?><urlset><url><loc>https://example.com/same</loc></url></urlset>`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, child)
+	}))
+	defer srv.Close()
+
+	indexXML := fmt.Sprintf(`<sitemapindex>
+  <sitemap><loc>%s/s1.xml</loc></sitemap>
+  <sitemap><loc>%s/s2.xml</loc></sitemap>
+</sitemapindex>`, srv.URL, srv.URL)
+
+	doc := makeDoc(t, indexXML)
+	pages := newTestCrawler(t, Options{Concurrency: 5, Timeout: 5 * time.Second}).parseSitemap(context.Background(), doc)
+
+	if len(pages) != 1 {
+		t.Errorf("expected 1 deduplicated page, got %d: %v", len(pages), pages)
+	}
+}
+
+// ---- getXML -------------------------------------------------------------
+
+func TestGetXML_Success(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != DefaultUserAgent {
+			t.Errorf("unexpected User-Agent: %q", r.Header.Get("User-Agent"))
+		}
+		fmt.Fprint(w, `<urlset></urlset>`)
+	}))
+	defer srv.Close()
+
+	resp, err := newTestCrawler(t, Options{Timeout: 5 * time.Second}).getXML(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestGetXML_NetworkError(t *testing.T) {
+	t.Parallel()
+	_, err := newTestCrawler(t, Options{Timeout: 2 * time.Second}).getXML(context.Background(), "http://127.0.0.1:1/sitemap.xml")
+	if err == nil {
+		t.Error("expected error for unreachable server, got nil")
+	}
+}
+
+// ---- getSitemap ---------------------------------------------------------
+
+func TestGetSitemap_ParsesURLSet(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset>
+  <url><loc>https://example.com/</loc></url>
+  <url><loc>https://example.com/about</loc></url>
+</urlset>`)
+	}))
+	defer srv.Close()
+
+	pages, err := newTestCrawler(t, Options{Concurrency: 5, Timeout: 5 * time.Second}).getSitemap(context.Background(), srv.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Errorf("expected 2 pages, got %d: %v", len(pages), pages)
+	}
+}
+
+func TestGetSitemap_NetworkError(t *testing.T) {
+	t.Parallel()
+	_, err := newTestCrawler(t, Options{Concurrency: 5, Timeout: 2 * time.Second}).getSitemap(context.Background(), "http://127.0.0.1:1/sitemap.xml")
+	if err == nil {
+		t.Error("expected error for unreachable server, got nil")
+	}
+}
+
+func TestGetSitemap_NotFound(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := newTestCrawler(t, Options{Timeout: 2 * time.Second}).getSitemap(context.Background(), srv.URL+"/sitemap.xml")
+	if err == nil {
+		t.Error("expected error for a sitemap answering 404, got nil")
+	}
+}
+
+func TestGetSitemap_IndexSkipsMissingChildren(t *testing.T) {
+	t.Parallel()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap_index.xml":
+			fmt.Fprintf(w, `<sitemapindex>
+  <sitemap><loc>%[1]s/missing.xml</loc></sitemap>
+  <sitemap><loc>%[1]s/child.xml</loc></sitemap>
+</sitemapindex>`, srv.URL)
+		case "/child.xml":
+			fmt.Fprint(w, `<urlset><url><loc>https://example.com/page1</loc></url></urlset>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	pages, err := newTestCrawler(t, Options{Timeout: 2 * time.Second}).getSitemap(context.Background(), srv.URL+"/sitemap_index.xml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pages) != 1 || pages[0].URL != "https://example.com/page1" {
+		t.Errorf("pages = %v, want [https://example.com/page1]", pages)
+	}
+}
+
+func TestGetSitemap_InvalidResponse(t *testing.T) {
+	t.Parallel()
+	// Server closes the connection immediately without sending an HTTP response.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hj := w.(http.Hijacker)
+		conn, _, _ := hj.Hijack()
+		conn.Close()
+	}))
+	defer srv.Close()
+
+	_, err := newTestCrawler(t, Options{Concurrency: 5, Timeout: 2 * time.Second}).getSitemap(context.Background(), srv.URL+"/sitemap.xml")
+	if err == nil {
+		t.Error("expected error when server closes connection, got nil")
+	}
+}
+
+// ---- gzip sitemaps ------------------------------------------------------
+
+// gzipBytes compresses s for serving as a .gz sitemap.
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGetSitemap_Gzip(t *testing.T) {
+	t.Parallel()
+	child := gzipBytes(t, `<urlset><url><loc>https://example.com/page1</loc></url></urlset>`)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap_index.xml.gz":
+			w.Header().Set("Content-Type", "application/x-gzip")
+			w.Write(gzipBytes(t, fmt.Sprintf(`<sitemapindex><sitemap><loc>%s/child.xml.gz</loc></sitemap></sitemapindex>`, srv.URL)))
+		case "/child.xml.gz":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(child)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	pages, err := newTestCrawler(t, Options{Timeout: 5 * time.Second}).getSitemap(context.Background(), srv.URL+"/sitemap_index.xml.gz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pages) != 1 || pages[0].URL != "https://example.com/page1" {
+		t.Errorf("pages = %v, want [https://example.com/page1]", pages)
+	}
+}
+
+func TestGetSitemap_GzipContentEncoding(t *testing.T) {
+	t.Parallel()
+	// The transport decodes Content-Encoding: gzip itself, so the body
+	// arrives as plain XML despite the .gz extension.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gzipBytes(t, `<urlset><url><loc>https://example.com/page1</loc></url></urlset>`))
+	}))
+	defer srv.Close()
+
+	pages, err := newTestCrawler(t, Options{Timeout: 5 * time.Second}).getSitemap(context.Background(), srv.URL+"/sitemap.xml.gz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pages) != 1 {
+		t.Errorf("expected 1 page, got %d: %v", len(pages), pages)
+	}
+}
+
+func TestGetSitemap_CorruptGzip(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte{0x1f, 0x8b, 0x00})
+	}))
+	defer srv.Close()
+
+	_, err := newTestCrawler(t, Options{Timeout: 5 * time.Second}).getSitemap(context.Background(), srv.URL+"/sitemap.xml.gz")
+	if err == nil {
+		t.Error("expected error for corrupt gzip body, got nil")
+	}
+}
+
+// ---- sitemap formats ----------------------------------------------------
+
+func TestDetectSitemapFormat(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		data string
+		want sitemapFormat
+	}{
+		{"urlset", `<?xml version="1.0"?><urlset></urlset>`, formatXML},
+		{"sitemap index", `<sitemapindex></sitemapindex>`, formatXML},
+		{"text sitemap", "https://example.com/\nhttps://example.com/about\n", formatText},
+		{"text sitemap with BOM", "\ufeffhttps://example.com/", formatText},
+		{"RSS 2.0", `<?xml version="1.0"?><rss version="2.0"><channel></channel></rss>`, formatRSS},
+		{"RSS 1.0", `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"></rdf:RDF>`, formatRSS},
+		{"Atom", `<feed xmlns="http://www.w3.org/2005/Atom"></feed>`, formatAtom},
+		{"HTML error page", `<!DOCTYPE html><html><body>Not found</body></html>`, formatXML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := detectSitemapFormat([]byte(tt.data)); got != tt.want {
+				t.Errorf("detectSitemapFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTextSitemap(t *testing.T) {
+	t.Parallel()
+	data := "https://example.com/\r\n\n  https://example.com/about  \nnot a url\nftp://example.com/file\n/relative\n"
+	got := parseTextSitemap([]byte(data))
+	want := []string{"https://example.com/", "https://example.com/about"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseTextSitemap() = %v, want %v", got, want)
+	}
+}
+
+func TestGetSitemap_Feeds(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.txt":
+			fmt.Fprint(w, "https://example.com/a\nhttps://example.com/b\n")
+		case "/rss.xml":
+			fmt.Fprint(w, `<rss><channel><item><link>https://example.com/a</link></item></channel></rss>`)
+		case "/atom.xml":
+			fmt.Fprint(w, `<feed xmlns="http://www.w3.org/2005/Atom"><entry><link href="https://example.com/a"/></entry></feed>`)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/sitemap.txt", 2},
+		{"/rss.xml", 1},
+		{"/atom.xml", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			pages, err := newTestCrawler(t, Options{Timeout: 5 * time.Second}).getSitemap(context.Background(), srv.URL+tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(pages) != tt.want {
+				t.Errorf("expected %d pages, got %d: %v", tt.want, len(pages), pages)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// spider crawls the site breadth-first from the entrypoint, following only
+// links to allowed hosts, fills in the pages fetched and passes the links
+// found on them on to check. Links to other hosts are checked but never
+// crawled. Options.MaxPages counts only the pages actually scraped,
+// not those left out by Options.Pages or robots.txt.
+func (c *Crawler) spider(ctx context.Context, report *Report, check *checks) {
+	var pages []string
+	start := c.entrypoint
+	if u, err := url.Parse(start); err == nil {
+		normalizeLink(u)
+		start = u.String()
+	}
+	visited := map[string]bool{start: true}
+	frontier := []string{start}
+
+	for depth := 0; len(frontier) > 0 && ctx.Err() == nil; depth++ {
+		if frontier = c.selectPages(ctx, report, frontier); len(frontier) == 0 {
+			break
+		}
+		if left := c.opts.MaxPages - len(pages); c.opts.MaxPages > 0 && len(frontier) > left {
+			frontier = frontier[:left]
+		}
+		c.logf("Spidering %d page(s) at depth %d\n", len(frontier), depth)
+		pages = append(pages, frontier...)
+
+		var next []string
+		c.scrapePages(ctx, frontier, func(_ string, pageLinks []Link) {
+			check.add(pageLinks)
+			if c.opts.MaxDepth > 0 && depth >= c.opts.MaxDepth {
+				return
+			}
+			for _, link := range pageLinks {
+				if visited[link.URL] || !isFollowable(link) || !isAllowedHost(link.URL, c.hosts) {
+					continue
+				}
+				visited[link.URL] = true
+				next = append(next, link.URL)
+			}
+		})
+		frontier = next
+		if c.opts.MaxPages > 0 && len(pages) >= c.opts.MaxPages {
+			break
+		}
+	}
+
+	report.Pages = pages
+}
+
+// isFollowable reports whether the spider should crawl the target of link.
+// Only anchors and frames lead to other pages; images, scripts and other
+// assets are only checked.
+func isFollowable(link Link) bool {
+	for _, o := range link.Origins {
+		if o.Kind == KindAnchor || o.Kind == KindFrame {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedHosts returns the set of hosts that make up the site: the
+// entrypoint's host plus any extra hosts, all lower-cased.
+func allowedHosts(entrypoint string, extra []string) map[string]bool {
+	allowed := make(map[string]bool)
+	if u, err := url.Parse(entrypoint); err == nil {
+		allowed[strings.ToLower(u.Host)] = true
+	}
+	for _, host := range extra {
+		allowed[strings.ToLower(strings.TrimSpace(host))] = true
+	}
+	return allowed
+}
+
+// isAllowedHost reports whether rawURL points at one of the allowed hosts.
+// Hosts match with or without an explicit port.
+func isAllowedHost(rawURL string, allowed map[string]bool) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return allowed[strings.ToLower(u.Host)] || allowed[strings.ToLower(u.Hostname())]
+}
@@ -0,0 +1,193 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newSpiderServer serves a small site where / links to /a and an external
+// host, /a links to /b and /b links back to /.
+func newSpiderServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<a href="/a">A</a><a href="https://external.example/x">External</a>`)
+		case "/a":
+			fmt.Fprint(w, `<a href="/b">B</a>`)
+		case "/b":
+			fmt.Fprint(w, `<a href="/">Home</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// spider spiders the site of c, checking the links found like Run does, and
+// returns the report.
+func spider(c *Crawler) *Report {
+	report := &Report{}
+	check := c.startChecks(context.Background())
+	c.spider(context.Background(), report, check)
+	check.finish(report)
+	return report
+}
+
+// ---- spider -------------------------------------------------------------
+
+func TestSpider(t *testing.T) {
+	t.Parallel()
+	srv := newSpiderServer(t)
+	tests := []struct {
+		name      string
+		opts      Options
+		wantPages []string
+		wantLinks int
+	}{
+		{
+			name:      "unlimited",
+			opts:      Options{},
+			wantPages: []string{"/", "/a", "/b"},
+			wantLinks: 4,
+		},
+		{
+			name:      "depth limited",
+			opts:      Options{MaxDepth: 1},
+			wantPages: []string{"/", "/a"},
+			wantLinks: 3,
+		},
+		{
+			name:      "page limited",
+			opts:      Options{MaxPages: 1},
+			wantPages: []string{"/"},
+			wantLinks: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Spider = true
+			tt.opts.Timeout = 5 * time.Second
+			c, err := New(srv.URL+"/", tt.opts)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			report := spider(c)
+			pages, links := report.Pages, report.Links
+
+			var want []string
+			for _, p := range tt.wantPages {
+				want = append(want, srv.URL+p)
+			}
+			if !slices.Equal(pages, want) {
+				t.Errorf("pages = %v, want %v", pages, want)
+			}
+			if len(links) != tt.wantLinks {
+				t.Errorf("expected %d links, got %d: %v", tt.wantLinks, len(links), links)
+			}
+		})
+	}
+}
+
+func TestSpider_NeverCrawlsExternalHosts(t *testing.T) {
+	t.Parallel()
+	var externalHits atomic.Int32
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Links are checked with HEAD, crawling takes a GET
+		if r.Method == http.MethodGet {
+			externalHits.Add(1)
+		}
+		fmt.Fprint(w, `<a href="/deeper">Deeper</a>`)
+	}))
+	defer external.Close()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<a href="%s/page">External</a>`, external.URL)
+	}))
+	defer site.Close()
+
+	c, err := New(site.URL+"/", Options{Spider: true, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report := spider(c)
+	pages, links := report.Pages, report.Links
+
+	if len(pages) != 1 {
+		t.Errorf("expected only the start page to be crawled, got %v", pages)
+	}
+	if len(links) != 1 || links[0].URL != external.URL+"/page" {
+		t.Errorf("expected the external link to be collected, got %v", links)
+	}
+	if hits := externalHits.Load(); hits != 0 {
+		t.Errorf("external host was fetched %d times during spidering", hits)
+	}
+}
+
+func TestSpider_BareEntrypointIsTheRootPage(t *testing.T) {
+	t.Parallel()
+	srv := newSpiderServer(t)
+
+	c, err := New(srv.URL, Options{Spider: true, MaxDepth: 1, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report := spider(c)
+	if want := []string{srv.URL + "/", srv.URL + "/a"}; !slices.Equal(report.Pages, want) {
+		t.Errorf("pages = %v, want %v", report.Pages, want)
+	}
+}
+
+func TestSpider_MaxPagesCountsScrapedPages(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="/skip1">1</a><a href="/skip2">2</a><a href="/c">C</a>`)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL+"/", Options{
+		Spider:   true,
+		MaxPages: 2,
+		Pages:    URLFilter{Exclude: []string{"*/skip*"}},
+		Timeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	report := spider(c)
+	if want := []string{srv.URL + "/", srv.URL + "/c"}; !slices.Equal(report.Pages, want) {
+		t.Errorf("pages = %v, want %v", report.Pages, want)
+	}
+}
+
+// ---- isAllowedHost ------------------------------------------------------
+
+func TestIsAllowedHost(t *testing.T) {
+	t.Parallel()
+	allowed := map[string]bool{"example.com": true, "localhost:8080": true}
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/page", true},
+		{"https://EXAMPLE.com/page", true},
+		{"https://example.com:8443/page", true},
+		{"http://localhost:8080/", true},
+		{"http://localhost:9090/", false},
+		{"https://other.com/", false},
+		{"https://sub.example.com/", false},
+	}
+	for _, tt := range tests {
+		if got := isAllowedHost(tt.url, allowed); got != tt.want {
+			t.Errorf("isAllowedHost(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
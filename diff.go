@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ewenson/sitemap_crawler/crawler"
+)
+
+// reportNamePattern matches the report file names generated when -output is
+// not set: report_<host>_<timestamp>.<ext>.
+var reportNamePattern = regexp.MustCompile(`^report_(.+)_(\d+)\.(csv|json|jsonl)$`)
+
+// diffCommand implements "diff", which compares the problems in two reports
+// and fails only on new ones. Given a single report, it compares it with
+// the previous report of the same site next to it. It returns the exit code.
+func diffCommand(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	failOn := fs.String("fail-on", "broken,error,anchor", "Comma separated result classes that count as problems: broken, 3xx, 4xx, 5xx, error, anchor, warning")
+	failThreshold := fs.Int("fail-threshold", 0, "Number of new problems tolerated before the command fails")
+	format := fs.String("format", "text", "Output format: text or json")
+	output := fs.String("output", "-", "File to write the differences to, - for stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sitemap_crawler diff [flags] [<old report>] <new report>")
+		fmt.Fprintln(fs.Output(), "Reports are JSON, JSON Lines or CSV. Without an old report, the previous report of the same site in the new report's directory is used.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitConfigError
+	}
+	policy, err := parseFailOn(*failOn, *failThreshold)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitConfigError
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 || (*format != "text" && *format != formatJSON) {
+		fs.Usage()
+		return exitConfigError
+	}
+
+	oldFile, newFile := fs.Arg(0), fs.Arg(1)
+	if fs.NArg() == 1 {
+		newFile = fs.Arg(0)
+		if oldFile, err = previousReport(newFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitConfigError
+		}
+	}
+	before, err := crawler.ReadReportFile(oldFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCrawlFailed
+	}
+	after, err := crawler.ReadReportFile(newFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCrawlFailed
+	}
+	d := crawler.DiffReports(before, after, policy.matches)
+
+	if err := writeDiff(*output, *format, oldFile, newFile, d); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing differences: %v\n", err)
+		return exitCrawlFailed
+	}
+	if len(d.New) > policy.threshold {
+		return exitLinksFailed
+	}
+	return exitOK
+}
+
+// previousReport returns the newest report of the same site that is older
+// than filename and in the same directory, going by the generated report
+// file names.
+func previousReport(filename string) (string, error) {
+	m := reportNamePattern.FindStringSubmatch(filepath.Base(filename))
+	if m == nil {
+		return "", fmt.Errorf("%s is not named like a generated report, give the report to compare with", filename)
+	}
+	host, start := m[1], m[2]
+	dir := filepath.Dir(filename)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var previous string
+	var previousStart int64
+	current, _ := strconv.ParseInt(start, 10, 64)
+	for _, e := range entries {
+		m := reportNamePattern.FindStringSubmatch(e.Name())
+		if m == nil || m[1] != host {
+			continue
+		}
+		t, err := strconv.ParseInt(m[2], 10, 64)
+		if err != nil || t >= current || t <= previousStart {
+			continue
+		}
+		previous, previousStart = filepath.Join(dir, e.Name()), t
+	}
+	if previous == "" {
+		return "", fmt.Errorf("no report of %s older than %s in %s", host, filename, dir)
+	}
+	return previous, nil
+}
+
+// diffCounts counts the links in each set of a crawler.ReportDiff.
+type diffCounts struct {
+	New        int `json:"new"`
+	Fixed      int `json:"fixed"`
+	Persisting int `json:"persisting"`
+}
+
+// diffJSON is the JSON output of the diff command.
+type diffJSON struct {
+	OldReport string     `json:"old_report"`
+	NewReport string     `json:"new_report"`
+	Summary   diffCounts `json:"summary"`
+	*crawler.ReportDiff
+}
+
+// writeDiff writes d to filename, or to stdout for "-".
+func writeDiff(filename, format, oldFile, newFile string, d *crawler.ReportDiff) error {
+	var w io.WriteCloser = nopCloser{os.Stdout}
+	if filename != "-" {
+		file, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		w = file
+	}
+	var err error
+	if format == formatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(diffJSON{
+			OldReport:  oldFile,
+			NewReport:  newFile,
+			Summary:    diffCounts{New: len(d.New), Fixed: len(d.Fixed), Persisting: len(d.Persisting)},
+			ReportDiff: d,
+		})
+	} else {
+		err = writeDiffText(w, oldFile, newFile, d)
+	}
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// writeDiffText writes d as a plain text list per set.
+func writeDiffText(w io.Writer, oldFile, newFile string, d *crawler.ReportDiff) error {
+	fmt.Fprintf(w, "Comparing %s with %s\n", newFile, oldFile)
+	for _, set := range []struct {
+		title   string
+		results []crawler.LinkResult
+	}{
+		{"New problems", d.New},
+		{"Fixed problems", d.Fixed},
+		{"Persisting problems", d.Persisting},
+	} {
+		fmt.Fprintf(w, "\n%s (%d):\n", set.title, len(set.results))
+		for _, r := range set.results {
+			fmt.Fprintf(w, "  %s\n", describeProblem(r))
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d new, %d fixed, %d persisting\n", len(d.New), len(d.Fixed), len(d.Persisting))
+	return err
+}
+
+// describeProblem returns a one line description of a problem and where it
+// was found.
+func describeProblem(r crawler.LinkResult) string {
+	var s string
+	switch r.Category {
+	case crawler.CategoryMissingAnchor:
+		s = fmt.Sprintf("%s#%s: missing anchor", r.URL, r.Fragment)
+	case crawler.CategoryRequestError, crawler.CategoryRedirectLoop:
+		s = fmt.Sprintf("%s: %s", r.URL, r.Error)
+	case crawler.CategoryWarning:
+		s = fmt.Sprintf("%s: %s", r.URL, strings.Join(r.Warnings, ", "))
+	default:
+		s = fmt.Sprintf("%s: %d %s", r.URL, r.StatusCode, r.Status)
+	}
+	switch len(r.Origins) {
+	case 0:
+	case 1:
+		s += " (on " + r.Origins[0].URL + ")"
+	default:
+		s += fmt.Sprintf(" (on %s and %d more pages)", r.Origins[0].URL, len(r.Origins)-1)
+	}
+	return s
+}
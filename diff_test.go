@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ewenson/sitemap_crawler/crawler"
+)
+
+// fixedReport is failReport a week later: the 404 was fixed and a new link
+// broke.
+func fixedReport() *crawler.Report {
+	report := failReport()
+	home := []crawler.Origin{{URL: "https://example.com/"}}
+	missing := crawler.CrawlResponse{URL: "https://example.com/missing", StatusCode: 404, Origins: home}
+	report.Broken = append(report.Broken[1:], missing)
+	return report
+}
+
+// ---- diffCommand --------------------------------------------------------
+
+func TestDiffCommand(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	oldFile := filepath.Join(dir, "old.csv")
+	newFile := filepath.Join(dir, "new.json")
+	diffFile := filepath.Join(dir, "diff.json")
+	if err := crawler.WriteCSVReport(oldFile, failReport()); err != nil {
+		t.Fatalf("WriteCSVReport() error = %v", err)
+	}
+	if err := crawler.WriteJSONReport(newFile, fixedReport()); err != nil {
+		t.Fatalf("WriteJSONReport() error = %v", err)
+	}
+
+	args := []string{"-format", "json", "-output", diffFile, oldFile, newFile}
+	if code := diffCommand(args); code != exitLinksFailed {
+		t.Errorf("diffCommand() = %d, want %d", code, exitLinksFailed)
+	}
+	data, err := os.ReadFile(diffFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got diffJSON
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	// The 500, the request error and the missing anchor persist
+	if want := (diffCounts{New: 1, Fixed: 1, Persisting: 3}); got.Summary != want {
+		t.Errorf("summary = %+v, want %+v", got.Summary, want)
+	}
+	if len(got.New) != 1 || got.New[0].URL != "https://example.com/missing" {
+		t.Errorf("new = %+v, want https://example.com/missing", got.New)
+	}
+	if len(got.Fixed) != 1 || got.Fixed[0].URL != "https://example.com/gone" {
+		t.Errorf("fixed = %+v, want https://example.com/gone", got.Fixed)
+	}
+
+	// Only fixes: the gate passes
+	args = []string{"-output", os.DevNull, oldFile, oldFile}
+	if code := diffCommand(args); code != exitOK {
+		t.Errorf("diffCommand() without new problems = %d, want %d", code, exitOK)
+	}
+	args = []string{"-fail-threshold", "1", "-output", os.DevNull, oldFile, newFile}
+	if code := diffCommand(args); code != exitOK {
+		t.Errorf("diffCommand() within the threshold = %d, want %d", code, exitOK)
+	}
+}
+
+func TestDiffCommand_Text(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	oldFile := filepath.Join(dir, "report_example.com_1700000000.json")
+	newFile := filepath.Join(dir, "report_example.com_1700600000.json")
+	diffFile := filepath.Join(dir, "diff.txt")
+	if err := crawler.WriteJSONReport(oldFile, failReport()); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.WriteJSONReport(newFile, fixedReport()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Compared with the previous report next to it
+	if code := diffCommand([]string{"-output", diffFile, newFile}); code != exitLinksFailed {
+		t.Errorf("diffCommand() = %d, want %d", code, exitLinksFailed)
+	}
+	data, err := os.ReadFile(diffFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Comparing " + newFile + " with " + oldFile,
+		"New problems (1):\n  https://example.com/missing: 404 Not Found (on https://example.com/)",
+		"Fixed problems (1):\n  https://example.com/gone: 404 Not Found",
+		"https://example.com/docs#install: missing anchor",
+		"1 new, 1 fixed, 3 persisting",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("output does not contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestDiffCommand_Errors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.json")
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{nil, exitConfigError},
+		{[]string{"a.json", "b.json", "c.json"}, exitConfigError},
+		{[]string{"-format", "csv", "a.json", "b.json"}, exitConfigError},
+		{[]string{"-fail-on", "teapot", "a.json", "b.json"}, exitConfigError},
+		// No previous report to compare with
+		{[]string{filepath.Join(dir, "report_example.com_1700000000.json")}, exitConfigError},
+		{[]string{filepath.Join(dir, "custom.json")}, exitConfigError},
+		{[]string{"-output", os.DevNull, missing, missing}, exitCrawlFailed},
+	}
+	for _, tt := range tests {
+		if got := diffCommand(tt.args); got != tt.want {
+			t.Errorf("diffCommand(%q) = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+// ---- previousReport -----------------------------------------------------
+
+func TestPreviousReport(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{
+		"report_example.com_100.csv",
+		"report_example.com_200.json",
+		"report_example.com_300.html",
+		"report_example.org_250.json",
+		"report_example.com_400.json",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := previousReport(filepath.Join(dir, "report_example.com_400.json"))
+	if err != nil {
+		t.Fatalf("previousReport() error = %v", err)
+	}
+	if want := filepath.Join(dir, "report_example.com_200.json"); got != want {
+		t.Errorf("previousReport() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/term"
+
+	"ewenson/sitemap_crawler/crawler"
+)
+
+// Exit codes of the command.
+const (
+	exitOK = 0
+	// exitLinksFailed means links matching -fail-on were found.
+	exitLinksFailed = 1
+	// exitCrawlFailed means the sitemap could not be fetched or the report
+	// could not be written.
+	exitCrawlFailed = 2
+	// exitConfigError means the command line was invalid.
+	exitConfigError = 3
+	// exitAborted means the crawl was declined at the prompt or interrupted.
+	exitAborted = 4
+)
+
+// failClassPattern matches the HTTP status classes accepted by -fail-on.
+var failClassPattern = regexp.MustCompile(`^[1-9]xx$`)
+
+// failPolicy decides whether the problems found should fail the run.
+type failPolicy struct {
+	// classes holds the result classes (see crawler.LinkResult.Class) that
+	// count as failures. "broken" matches every broken HTTP status.
+	classes map[string]bool
+	// threshold is the number of failing links tolerated.
+	threshold int
+}
+
+// parseFailOn parses a comma separated list of result classes for -fail-on:
+// broken, an HTTP status class such as 4xx, error, anchor or warning.
+func parseFailOn(s string, threshold int) (failPolicy, error) {
+	policy := failPolicy{classes: make(map[string]bool), threshold: threshold}
+	if threshold < 0 {
+		return policy, fmt.Errorf("fail threshold must not be negative, got %d", threshold)
+	}
+	for _, class := range strings.Split(s, ",") {
+		class = strings.ToLower(strings.TrimSpace(class))
+		switch {
+		case class == "":
+			continue
+		case class == "broken", class == "error", class == "anchor", class == "warning", failClassPattern.MatchString(class):
+			policy.classes[class] = true
+		default:
+			return policy, fmt.Errorf("unknown -fail-on class %q", class)
+		}
+	}
+	return policy, nil
+}
+
+// matches reports whether result counts as a failure.
+func (p failPolicy) matches(result crawler.LinkResult) bool {
+	if result.Category == crawler.CategoryBroken && p.classes["broken"] {
+		return true
+	}
+	return p.classes[result.Class()]
+}
+
+// failures returns the number of links in report that count as failures.
+func (p failPolicy) failures(report *crawler.Report) int {
+	n := 0
+	for _, result := range report.LinkResults() {
+		if p.matches(result) {
+			n++
+		}
+	}
+	return n
+}
+
+// exitCode returns the exit code for a finished crawl.
+func (p failPolicy) exitCode(report *crawler.Report) int {
+	if p.failures(report) > p.threshold {
+		return exitLinksFailed
+	}
+	return exitOK
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal, so the
+// user can be prompted.
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// fatalf prints an error and exits with code.
+func fatalf(code int, format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format, args...)
+	os.Exit(code)
+}
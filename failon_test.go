@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"ewenson/sitemap_crawler/crawler"
+)
+
+// failReport returns a report with a 404, a 500, a warning, a request error
+// and a missing anchor.
+func failReport() *crawler.Report {
+	home := []crawler.Origin{{URL: "https://example.com/"}}
+	notFound := crawler.CrawlResponse{URL: "https://example.com/gone", StatusCode: 404, Origins: home}
+	serverError := crawler.CrawlResponse{URL: "https://example.com/down", StatusCode: 500, Origins: home}
+	warned := crawler.CrawlResponse{
+		URL: "https://example.com/old", StatusCode: 200, OK: true,
+		Warnings: []string{"Permanent redirect"},
+	}
+	return &crawler.Report{
+		OK:             1,
+		Broken:         []crawler.CrawlResponse{notFound, serverError},
+		Warnings:       []crawler.CrawlResponse{warned},
+		RequestErrors:  []crawler.RequestError{{URL: "https://example.com/timeout", Err: errors.New("timeout"), Origins: home}},
+		MissingAnchors: []crawler.MissingAnchor{{URL: "https://example.com/docs", Fragment: "install", Origins: home}},
+	}
+}
+
+// ---- parseFailOn --------------------------------------------------------
+
+func TestParseFailOn_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		classes   string
+		threshold int
+	}{
+		{"broken,teapot", 0},
+		{"0xx", 0},
+		{"4XXX", 0},
+		{"broken", -1},
+	} {
+		if _, err := parseFailOn(tt.classes, tt.threshold); err == nil {
+			t.Errorf("parseFailOn(%q, %d): expected an error", tt.classes, tt.threshold)
+		}
+	}
+}
+
+// ---- failPolicy ---------------------------------------------------------
+
+func TestFailPolicy_Failures(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		classes string
+		want    int
+	}{
+		{"broken,error,anchor", 4},
+		{"broken", 2},
+		{"4xx", 1},
+		{"4xx, 5XX", 2},
+		{"error", 1},
+		{"anchor", 1},
+		{"warning", 1},
+		{"", 0},
+	}
+	report := failReport()
+	for _, tt := range tests {
+		policy, err := parseFailOn(tt.classes, 0)
+		if err != nil {
+			t.Fatalf("parseFailOn(%q): unexpected error: %v", tt.classes, err)
+		}
+		if got := policy.failures(report); got != tt.want {
+			t.Errorf("failures(%q) = %d, want %d", tt.classes, got, tt.want)
+		}
+	}
+}
+
+func TestFailPolicy_ExitCode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		classes   string
+		threshold int
+		want      int
+	}{
+		{"broken", 0, exitLinksFailed},
+		{"broken", 1, exitLinksFailed},
+		{"broken", 2, exitOK},
+		{"5xx", 0, exitLinksFailed},
+		{"3xx", 0, exitOK},
+	}
+	report := failReport()
+	for _, tt := range tests {
+		policy, err := parseFailOn(tt.classes, tt.threshold)
+		if err != nil {
+			t.Fatalf("parseFailOn(%q): unexpected error: %v", tt.classes, err)
+		}
+		if got := policy.exitCode(report); got != tt.want {
+			t.Errorf("exitCode(%q, threshold %d) = %d, want %d", tt.classes, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestFailPolicy_ExitCodeEmptyReport(t *testing.T) {
+	t.Parallel()
+	policy, _ := parseFailOn("broken,error,anchor", 0)
+	if got := policy.exitCode(&crawler.Report{}); got != exitOK {
+		t.Errorf("exitCode = %d, want %d", got, exitOK)
+	}
+}
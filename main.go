@@ -3,538 +3,335 @@
 // back. If a link returns responses in the 3xx, 4xx or 5xx ranges,
 // the software shall print that to console and/or log file for
 // the user to handle later.
+//
+// The crawling itself lives in the crawler package; this is a thin
+// command line wrapper around it.
 
 package main
 
 import (
-	"encoding/csv"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
-	"log"
-	"net/http"
+	"io"
+	"io/fs"
 	"net/url"
 	"os"
-	"strconv"
+	"os/signal"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
+	"ewenson/sitemap_crawler/crawler"
 )
 
-type RequestError struct {
-	err        error
-	url        string
-	originURL  string
-	originText string
-}
-
-type Link struct {
-	originURL  string
-	originText string
-	url        string
-}
-
-type CrawlResponse struct {
-	originURL  string
-	originText string
-	url        string
-	statusCode int
-	isOk       bool
-}
-
-const maxConcurrentURLChecks = 10
-const crawlerUserAgent = "Golang Link Crawler/1.0"
-const httpRequestMethod = "HEAD"
-const httpRequestTimeout = 60 * time.Second
+// console receives progress output and the summary. It is stdout unless
+// the report itself is written to stdout.
+var console io.Writer = os.Stdout
 
 func main() {
-	cliEntrypoint := flag.String("url", "", "Entrypoint URL")
-	cliConcurrentLimit := flag.Int("limit", maxConcurrentURLChecks, "Limit amount of concurrent scrapes")
-	cliRequestMethod := flag.String("method", httpRequestMethod, "Initial method, HEAD or GET")
-	cliTimeout := flag.Duration("timeout", httpRequestTimeout, "Timeout limit for each request")
+	if len(os.Args) > 1 && os.Args[1] == "baseline" {
+		os.Exit(baselineCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(diffCommand(os.Args[2:]))
+	}
+
+	cliConfig := flag.String("config", "", "YAML config file with default values for any of these flags")
+	cliSite := flag.String("site", "", "Name of the site profile in the config file to use")
+	cliEntrypoint := flag.String("url", "", "Sitemap URL, or site URL to discover sitemaps through robots.txt")
+	cliConcurrentLimit := flag.Int("limit", crawler.DefaultConcurrency, "Limit amount of concurrent scrapes")
+	cliHostLimit := flag.Int("host-limit", crawler.DefaultSiteHostConcurrency, "Limit amount of concurrent requests to each of the site's own hosts")
+	cliExternalHostLimit := flag.Int("external-host-limit", crawler.DefaultExternalHostConcurrency, "Limit amount of concurrent requests to each third-party host")
+	cliRate := flag.Float64("rate", 0, "Maximum requests per second to each of the site's own hosts, 0 for no limit")
+	cliExternalRate := flag.Float64("external-rate", 0, "Maximum requests per second to each third-party host, 0 for no limit")
+	cliDelay := flag.Duration("delay", 0, "Crawl delay between requests to each of the site's own hosts, e.g. 500ms")
+	cliAttempts := flag.Int("attempts", crawler.DefaultRetryAttempts, "Number of attempts for links answering 429 or 503 or failing with a network error")
+	cliRetryDelay := flag.Duration("retry-delay", crawler.DefaultRetryDelay, "Wait before the first retry, doubled for every further retry")
+	cliRetryMaxDelay := flag.Duration("retry-max-delay", crawler.DefaultRetryMaxDelay, "Longest wait between two attempts, including Retry-After")
+	cliRequestMethod := flag.String("method", crawler.DefaultMethod, "Initial method, HEAD or GET")
+	cliTimeout := flag.Duration("timeout", crawler.DefaultTimeout, "Timeout limit for each request")
 	cliVerify := flag.Bool("verify", true, "Ask user to verify crawl before continuing.")
-	cliLog := flag.Bool("log", false, "Write results to a plain text log file instead of CSV")
+	cliKinds := flag.String("check", "anchor", "Comma separated resource kinds to check: anchor, image, script, stylesheet, media, frame, object or all")
+	cliWarnPermanent := flag.Bool("warn-permanent", true, "Warn about links that answer with a permanent redirect (301/308)")
+	cliWarnDowngrade := flag.Bool("warn-downgrade", true, "Warn about links that redirect from HTTPS to HTTP")
+	cliFragments := flag.Bool("fragments", false, "Verify that #fragment links into the site point at an existing anchor")
+	cliSpider := flag.Bool("spider", false, "Crawl by following internal links from -url instead of reading a sitemap")
+	cliDepth := flag.Int("depth", 3, "Maximum link depth to follow in spider mode, 0 for no limit")
+	cliMaxPages := flag.Int("max-pages", 1000, "Maximum number of pages to crawl in spider mode, 0 for no limit")
+	cliHosts := flag.String("hosts", "", "Comma separated list of additional hosts to crawl in spider mode")
+	var includePages, excludePages, includeLinks, excludeLinks listFlag
+	flag.Var(&includePages, "include-pages", "Only scrape pages matching this glob, or regex with a re: prefix (repeatable)")
+	flag.Var(&excludePages, "exclude-pages", "Do not scrape pages matching this glob, or regex with a re: prefix (repeatable)")
+	flag.Var(&includeLinks, "include-links", "Only check links matching this glob, or regex with a re: prefix (repeatable)")
+	flag.Var(&excludeLinks, "exclude-links", "Do not check links matching this glob, or regex with a re: prefix, e.g. '*/wp-admin/*' (repeatable)")
+	var headers listFlag
+	flag.Var(&headers, "header", "Extra request header for the site's own hosts, e.g. 'X-Env: staging' (repeatable)")
+	cliCookies := flag.String("cookies", "", "Netscape cookies.txt file with cookies to send, e.g. a login session")
+	cliBasicAuth := flag.String("basic-auth", "", "Basic auth credentials for the site's own hosts as user:password")
+	cliBearerToken := flag.String("bearer-token", "", "Bearer token for the site's own hosts")
+	cliBaseline := flag.String("baseline", "", "YAML file of accepted problems to leave out of the report, see the baseline command")
+	cliIgnoreRobots := flag.Bool("ignore-robots", false, "Scrape pages even if robots.txt disallows them, e.g. for your own staging site")
+	cliCache := flag.String("cache", "", "File to keep third-party link results and page validators in between runs")
+	cliCacheTTL := flag.String("cache-ttl", "", "How long cached link results are reused per status class, e.g. '2xx=72h,4xx=1h' (default 2xx=168h,3xx=24h,4xx=24h,5xx=0)")
+	cliIncremental := flag.Bool("incremental", false, "Only scrape pages whose sitemap lastmod is newer than the previous incremental run, reusing the links found then")
+	cliState := flag.String("state", "", "State file of incremental runs, defaults to logs/state_<host>.json")
+	cliCheckpoint := flag.String("checkpoint", "", "File to save the progress of the crawl to, for continuing it with -resume")
+	cliCheckpointInterval := flag.Duration("checkpoint-interval", crawler.DefaultCheckpointInterval, "How often progress is saved to the -checkpoint file")
+	cliResume := flag.Bool("resume", false, "Continue an interrupted crawl from its -checkpoint file")
+	cliFormat := flag.String("format", formatCSV, "Report format: csv, log, json, jsonl, html or junit")
+	cliOutput := flag.String("output", "", "Report file name, defaults to logs/report_<host>_<timestamp>; - writes the report to stdout")
+	cliFailOn := flag.String("fail-on", "broken,error,anchor", "Comma separated result classes that fail the run: broken, 3xx, 4xx, 5xx, error, anchor, warning")
+	cliFailThreshold := flag.Int("fail-threshold", 0, "Number of failing links tolerated before the run fails")
+	cliLog := flag.Bool("log", false, "Write results to a plain text log file instead of CSV (same as -format log)")
 	flag.Parse()
 
+	if *cliConfig != "" {
+		if err := loadConfig(flag.CommandLine, *cliConfig, *cliSite); err != nil {
+			fatalf(exitConfigError, "%v\n", err)
+		}
+	} else if *cliSite != "" {
+		fatalf(exitConfigError, "-site needs a -config file\n")
+	}
+
+	format := *cliFormat
+	if *cliLog {
+		format = formatLog
+	}
+	if !isReportFormat(format) {
+		fatalf(exitConfigError, "Unknown report format %q\n", format)
+	}
+	policy, err := parseFailOn(*cliFailOn, *cliFailThreshold)
+	if err != nil {
+		fatalf(exitConfigError, "%v\n", err)
+	}
+	if *cliRate < 0 || *cliExternalRate < 0 || *cliDelay < 0 {
+		fatalf(exitConfigError, "Rates and delays must not be negative\n")
+	}
+	interactive := stdinIsTerminal()
+	if *cliOutput == "-" {
+		console = os.Stderr
+	}
+
 	var entrypoint string
 	if *cliEntrypoint != "" {
 		entrypoint = *cliEntrypoint
-	} else {
-		fmt.Print("Enter sitemap URL: ")
+	} else if interactive {
+		fmt.Fprint(console, "Enter sitemap or site URL: ")
 		fmt.Scanln(&entrypoint)
+	} else {
+		fatalf(exitConfigError, "No -url given\n")
 	}
 
-	concurrentLimit := *cliConcurrentLimit
-	requestMethod := *cliRequestMethod
-	timeout := *cliTimeout
-	verifyTest := *cliVerify
-	useLog := *cliLog
-
-	start := time.Now()
-	timestamp := start.Unix()
-
 	parsedEntrypoint, err := url.ParseRequestURI(entrypoint)
 	if err != nil {
-		log.Fatal(err)
+		fatalf(exitConfigError, "%v\n", err)
 	}
 
-	crawlURLs, err := getSitemap(entrypoint, concurrentLimit, timeout)
+	kinds, err := crawler.ParseKinds(*cliKinds)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		fatalf(exitConfigError, "%v\n", err)
 	}
 
-	httpClient := &http.Client{
-		Timeout:       timeout,
-		CheckRedirect: redirectTrim,
+	opts := crawler.Options{
+		Concurrency: *cliConcurrentLimit,
+		Method:      *cliRequestMethod,
+		Timeout:     *cliTimeout,
+		Redirects: crawler.RedirectPolicy{
+			WarnPermanent: *cliWarnPermanent,
+			WarnDowngrade: *cliWarnDowngrade,
+		},
+		Retry: crawler.RetryPolicy{
+			Attempts: *cliAttempts,
+			Delay:    *cliRetryDelay,
+			MaxDelay: *cliRetryMaxDelay,
+		},
+		SiteLimits: crawler.HostLimits{
+			Concurrency:       *cliHostLimit,
+			RequestsPerSecond: *cliRate,
+			Delay:             *cliDelay,
+		},
+		ExternalLimits: crawler.HostLimits{
+			Concurrency:       *cliExternalHostLimit,
+			RequestsPerSecond: *cliExternalRate,
+		},
+		Kinds:          kinds,
+		Pages:          crawler.URLFilter{Include: includePages, Exclude: excludePages},
+		Links:          crawler.URLFilter{Include: includeLinks, Exclude: excludeLinks},
+		IgnoreRobots:   *cliIgnoreRobots,
+		CheckFragments: *cliFragments,
+		Spider:         *cliSpider,
+		MaxDepth:       *cliDepth,
+		MaxPages:       *cliMaxPages,
+		Log:            console,
 	}
-	defer httpClient.CloseIdleConnections()
-
-	var (
-		allLinks []Link
-		linksMu  sync.Mutex
-		seenURLs = make(map[string]bool)
-	)
-
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, concurrentLimit)
-
-	for _, crawlURL := range crawlURLs {
-		wg.Add(1)
-		sem <- struct{}{}
-		go func(u string) {
-			defer wg.Done()
-			defer func() { <-sem }()
-			pageLinks := getPageLinks(u, httpClient)
-			linksMu.Lock()
-			for _, link := range pageLinks {
-				if !seenURLs[link.url] {
-					seenURLs[link.url] = true
-					allLinks = append(allLinks, link)
-				}
-			}
-			linksMu.Unlock()
-		}(crawlURL)
-	}
-	wg.Wait()
-
-	fmt.Println("A total of", len(allLinks), "links were found in", len(crawlURLs), "pages")
-
-	if verifyTest {
-		var userContinue string
-		fmt.Print("Continue verifying URLs? (y/n) ")
-		fmt.Scan(&userContinue)
-		if strings.ToLower(userContinue) != "y" {
-			os.Exit(1)
-		}
+	if *cliHosts != "" {
+		opts.AllowedHosts = strings.Split(*cliHosts, ",")
 	}
-	fmt.Println()
-
-	crawledURLs, urlErrors, requestErrors := checkURLStatus(allLinks, concurrentLimit, requestMethod, timeout)
-	numErrors := len(urlErrors)
-
-	var outputFileName string
-	if numErrors > 0 || len(requestErrors) > 0 {
-		if err := os.MkdirAll("./logs", 0755); err != nil {
-			log.Fatal(err)
-		}
-
-		if useLog {
-			outputFileName = "logs/result_" + parsedEntrypoint.Host + "_" + strconv.FormatInt(timestamp, 10) + ".log"
-			file, err := os.OpenFile(outputFileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-			if err != nil {
-				log.Fatalf("Error opening file: %v\n", err)
-			}
-			defer file.Close()
-			log.SetOutput(file)
-		} else {
-			outputFileName = "logs/report_" + parsedEntrypoint.Host + "_" + strconv.FormatInt(timestamp, 10) + ".csv"
-			if err := writeCSVReport(outputFileName, urlErrors, requestErrors); err != nil {
-				log.Fatalf("Error writing CSV report: %v\n", err)
-			}
-		}
+	creds, err := siteCredentials(headers, *cliBasicAuth, *cliBearerToken)
+	if err != nil {
+		fatalf(exitConfigError, "%v\n", err)
 	}
-
-	fmt.Println()
-	if len(requestErrors) > 0 {
-		fmt.Println("Errors raised while checking URLs")
-		if useLog {
-			for _, e := range requestErrors {
-				log.Printf("%v (linked from %v with text %v)\n", e.err, e.originURL, e.originText)
-			}
+	if creds != nil {
+		opts.Credentials = []crawler.HostCredentials{*creds}
+	}
+	if *cliCookies != "" {
+		if opts.Jar, err = crawler.LoadCookieFile(*cliCookies); err != nil {
+			fatalf(exitConfigError, "%v\n", err)
 		}
 	}
-
-	if numErrors > 0 && useLog {
-		for _, item := range urlErrors {
-			log.Printf("HTTP %d for %s (linked from %s with text %s)\n", item.statusCode, item.url, item.originURL, item.originText)
+	if *cliBaseline != "" {
+		if opts.Baseline, err = crawler.LoadBaseline(*cliBaseline); err != nil {
+			fatalf(exitConfigError, "%v\n", err)
 		}
 	}
-
-	fmt.Printf("\nA total of %d links on %d pages was checked and %d produced errors of some sort.\n", len(crawledURLs), len(crawlURLs), numErrors)
-	fmt.Println("Total execution time:", time.Since(start))
-
-	if outputFileName != "" {
-		if useLog {
-			fmt.Printf("\nErrors found. Check logfile (%v) for results.\n", outputFileName)
-		} else {
-			fmt.Printf("\nErrors found. Results saved to %v\n", outputFileName)
+	if *cliCache != "" {
+		ttl, err := crawler.ParseCacheTTL(*cliCacheTTL)
+		if err != nil {
+			fatalf(exitConfigError, "%v\n", err)
+		}
+		if opts.Cache, err = crawler.OpenCache(*cliCache); err != nil {
+			fatalf(exitConfigError, "%v\n", err)
 		}
+		opts.Cache.TTL = ttl
 	}
-}
-
-func writeCSVReport(filename string, urlErrors []CrawlResponse, requestErrors []RequestError) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+	if *cliCheckpointInterval <= 0 {
+		fatalf(exitConfigError, "-checkpoint-interval must be positive\n")
 	}
-
-	w := csv.NewWriter(file)
-
-	if err := w.Write([]string{
-		"Broken URL",
-		"HTTP Status Code",
-		"Status Description",
-		"Link Text",
-		"Page Where Link Was Found",
-	}); err != nil {
-		file.Close()
-		return err
+	if *cliCheckpoint != "" {
+		opts.Checkpoint, opts.CheckpointInterval = *cliCheckpoint, *cliCheckpointInterval
+	} else if *cliResume {
+		fatalf(exitConfigError, "-resume needs the -checkpoint file of the crawl to continue\n")
 	}
-
-	for _, item := range urlErrors {
-		statusDesc := http.StatusText(item.statusCode)
-		if statusDesc == "" {
-			statusDesc = "Unknown"
-		}
-		if err := w.Write([]string{
-			item.url,
-			strconv.Itoa(item.statusCode),
-			statusDesc,
-			item.originText,
-			item.originURL,
-		}); err != nil {
-			file.Close()
-			return err
+	if *cliResume {
+		opts.Resume, err = crawler.LoadCheckpoint(opts.Checkpoint)
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(console, "No checkpoint in %s, starting a new crawl\n", opts.Checkpoint)
+		} else if err != nil {
+			fatalf(exitConfigError, "%v\n", err)
 		}
 	}
-
-	for _, e := range requestErrors {
-		if err := w.Write([]string{
-			e.url,
-			"N/A",
-			e.err.Error(),
-			e.originText,
-			e.originURL,
-		}); err != nil {
-			file.Close()
-			return err
-		}
+	var stateFile string
+	if *cliIncremental && *cliSpider {
+		fatalf(exitConfigError, "-incremental relies on sitemap lastmod and cannot be used with -spider\n")
 	}
-
-	w.Flush()
-	if err := w.Error(); err != nil {
-		file.Close()
-		return err
+	if *cliIncremental {
+		if stateFile, err = logFile(*cliState, "state", parsedEntrypoint.Host); err != nil {
+			fatalf(exitConfigError, "%v\n", err)
+		}
+		opts.KeepLinks = true
+		opts.Previous, err = crawler.LoadState(stateFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(console, "No previous state in %s, scraping every page\n", stateFile)
+		} else if err != nil {
+			fatalf(exitConfigError, "%v\n", err)
+		}
 	}
-	return file.Close()
-}
-
-func redirectTrim(req *http.Request, via []*http.Request) error {
-	if len(via) >= 25 {
-		return errors.New("stopped after 25 redirects")
+	// Never prompt when nobody can answer, e.g. in CI
+	if *cliVerify && interactive {
+		opts.Confirm = confirmCrawl
 	}
-	return nil
-}
 
-func checkURLStatus(links []Link, concurrentLimit int, requestMethod string, timeout time.Duration) ([]CrawlResponse, []CrawlResponse, []RequestError) {
-	client := &http.Client{
-		CheckRedirect: redirectTrim,
-		Timeout:       timeout,
+	out := &output{
+		format:   format,
+		filename: *cliOutput,
+		host:     parsedEntrypoint.Host,
+		start:    time.Now(),
 	}
-	defer client.CloseIdleConnections()
-
-	var (
-		crawledURLs   []CrawlResponse
-		retryURLs     []Link
-		requestErrors []RequestError
-		mu            sync.Mutex
-	)
-
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, concurrentLimit)
-
-	for _, link := range links {
-		wg.Add(1)
-		sem <- struct{}{}
-		go func(input Link) {
-			defer wg.Done()
-			defer func() { <-sem }()
-
-			method := http.MethodHead
-			if requestMethod == "GET" {
-				method = http.MethodGet
-			}
-
-			req, err := http.NewRequest(method, input.url, nil)
-			if err != nil {
-				mu.Lock()
-				requestErrors = append(requestErrors, RequestError{
-					err:        err,
-					url:        input.url,
-					originURL:  input.originURL,
-					originText: input.originText,
-				})
-				mu.Unlock()
-				return
-			}
-			req.Header.Set("User-Agent", crawlerUserAgent)
-
-			resp, err := client.Do(req)
-			if err != nil {
-				fmt.Println("Request error:", err)
-				mu.Lock()
-				retryURLs = append(retryURLs, input)
-				mu.Unlock()
-				return
-			}
-			defer resp.Body.Close()
-
-			statusCode := resp.StatusCode
-			// Treat LinkedIn's non-standard 999 as OK
-			isOk := (statusCode >= 200 && statusCode <= 299) || statusCode == 999
-			fmt.Printf("%s response %d for %s\n", requestMethod, statusCode, input.url)
-
-			mu.Lock()
-			crawledURLs = append(crawledURLs, CrawlResponse{
-				originURL:  input.originURL,
-				originText: input.originText,
-				url:        input.url,
-				statusCode: statusCode,
-				isOk:       isOk,
-			})
-			mu.Unlock()
-		}(link)
-	}
-	wg.Wait()
-
-	// Retry with GET for any URLs that failed the initial request
-	if len(retryURLs) > 0 {
-		retryClient := &http.Client{Timeout: timeout, CheckRedirect: redirectTrim}
-		defer retryClient.CloseIdleConnections()
-
-		var retryWg sync.WaitGroup
-		retrySem := make(chan struct{}, concurrentLimit)
-
-		for _, link := range retryURLs {
-			retryWg.Add(1)
-			retrySem <- struct{}{}
-			go func(input Link) {
-				defer retryWg.Done()
-				defer func() { <-retrySem }()
 
-				req, err := http.NewRequest(http.MethodGet, input.url, nil)
-				if err != nil {
-					mu.Lock()
-					requestErrors = append(requestErrors, RequestError{
-						err:        err,
-						url:        input.url,
-						originURL:  input.originURL,
-						originText: input.originText,
-					})
-					mu.Unlock()
-					return
-				}
-				req.Header.Set("User-Agent", crawlerUserAgent)
-
-				resp, err := retryClient.Do(req)
-				if err != nil {
-					fmt.Println(err)
-					mu.Lock()
-					requestErrors = append(requestErrors, RequestError{
-						err:        err,
-						url:        input.url,
-						originURL:  input.originURL,
-						originText: input.originText,
-					})
-					mu.Unlock()
-					return
-				}
-				defer resp.Body.Close()
-
-				statusCode := resp.StatusCode
-				isOk := statusCode >= 200 && statusCode <= 299
-				fmt.Printf("GET response %d for %s\n", statusCode, input.url)
+	os.Exit(run(entrypoint, opts, out, policy, stateFile))
+}
 
-				mu.Lock()
-				crawledURLs = append(crawledURLs, CrawlResponse{
-					originURL:  input.originURL,
-					originText: input.originText,
-					url:        input.url,
-					statusCode: statusCode,
-					isOk:       isOk,
-				})
-				mu.Unlock()
-			}(link)
-		}
-		retryWg.Wait()
+// run crawls, writes the report and returns the exit code. If stateFile is
+// set, the state of a completed crawl is saved to it for the next
+// incremental run.
+func run(entrypoint string, opts crawler.Options, out *output, policy failPolicy, stateFile string) int {
+	if opts.Cache != nil {
+		defer opts.Cache.Close()
 	}
 
-	var urlErrors []CrawlResponse
-	for _, item := range crawledURLs {
-		if !item.isOk {
-			urlErrors = append(urlErrors, item)
+	// JSON and JSON Lines reports are streamed while links are checked, so
+	// the file is opened up front
+	if out.streams() {
+		w, err := out.stream(&opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening report: %v\n", err)
+			return exitCrawlFailed
 		}
+		defer w.Close()
 	}
 
-	return crawledURLs, urlErrors, requestErrors
-}
-
-// getPageLinks fetches a page and returns all unique HTTP(S) links found in it.
-func getPageLinks(inputURL string, client *http.Client) []Link {
-	parsedBase, err := url.Parse(inputURL)
+	c, err := crawler.New(entrypoint, opts)
 	if err != nil {
-		fmt.Printf("Failed to parse URL %s: %v\n", inputURL, err)
-		return nil
+		fmt.Fprintln(os.Stderr, err)
+		return exitConfigError
 	}
 
-	fmt.Println("Link scraping:", inputURL)
-
-	req, err := http.NewRequest(http.MethodGet, inputURL, nil)
-	if err != nil {
-		fmt.Printf("Failed to create request for %s: %v\n", inputURL, err)
-		return nil
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Once the crawl is winding down, a second signal ends the program
+	// right away
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	report, err := c.Run(ctx)
+	if errors.Is(err, crawler.ErrAborted) {
+		return exitAborted
 	}
-	req.Header.Set("User-Agent", crawlerUserAgent)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("Failed to fetch %s: %v\n", inputURL, err)
-		return nil
+	if report == nil {
+		fmt.Fprintln(console, err)
+		return exitCrawlFailed
 	}
-	defer resp.Body.Close()
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
-		fmt.Printf("Failed to parse HTML from %s: %v\n", inputURL, err)
-		return nil
+		fmt.Fprintln(console, err)
 	}
 
-	var links []Link
-	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
-		linkURL, exists := s.Attr("href")
-		if !exists {
-			return
-		}
-		linkText := strings.TrimSpace(s.Text())
-
-		parsedLink, err := url.Parse(linkURL)
-		if err != nil {
-			return
-		}
-
-		// Resolve relative URLs against the page base
-		resolved := parsedBase.ResolveReference(parsedLink)
-
-		// Skip non-HTTP schemes (mailto:, tel:, javascript:, etc.)
-		if resolved.Scheme != "http" && resolved.Scheme != "https" {
-			return
-		}
-
-		// Strip fragments — #section links point to the same resource
-		resolved.Fragment = ""
-
-		links = append(links, Link{
-			originURL:  inputURL,
-			originText: linkText,
-			url:        resolved.String(),
-		})
-	})
-
-	return links
-}
-
-func getSitemap(entrypoint string, concurrentLimit int, timeout time.Duration) ([]string, error) {
-	res, err := getXML(entrypoint, timeout)
-	if err != nil {
-		return nil, err
+	if err := out.write(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s report: %v\n", out.format, err)
+		return exitCrawlFailed
 	}
-	defer res.Body.Close()
-
-	doc, err := goquery.NewDocumentFromReader(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse sitemap XML: %w", err)
+	printSummary(report, out)
+	if opts.Baseline != nil {
+		printExpired(opts.Baseline, report.Start)
 	}
 
-	return parseSitemap(*doc, concurrentLimit, timeout), nil
-}
-
-func getXML(entrypoint string, timeout time.Duration) (*http.Response, error) {
-	client := &http.Client{Timeout: timeout}
-	req, err := http.NewRequest(http.MethodGet, entrypoint, nil)
-	if err != nil {
-		return nil, err
+	if ctx.Err() != nil {
+		fmt.Fprintln(console, "The crawl was interrupted, so the report is partial")
+		if opts.Checkpoint != "" {
+			fmt.Fprintln(console, "Run it again with -resume to continue where it stopped")
+		}
+		return exitAborted
 	}
-	req.Header.Set("User-Agent", crawlerUserAgent)
-	return client.Do(req)
-}
-
-// parseURLSet extracts all <loc> text values from a sitemap document.
-func parseURLSet(doc goquery.Document) []string {
-	var locations []string
-	doc.Find("loc").Each(func(_ int, s *goquery.Selection) {
-		if loc := strings.TrimSpace(s.Text()); loc != "" {
-			locations = append(locations, loc)
+	if stateFile != "" && err == nil {
+		if err := crawler.NewState(report).Save(stateFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving state: %v\n", err)
 		}
-	})
-	return locations
+	}
+	return policy.exitCode(report)
 }
 
-func parseSitemap(doc goquery.Document, concurrentLimit int, timeout time.Duration) []string {
-	if len(doc.Find("sitemap").Nodes) > 0 {
-		// Sitemap index: fetch each child sitemap concurrently
-		sitemapURLs := parseURLSet(doc)
-		var (
-			pages []string
-			mu    sync.Mutex
-			wg    sync.WaitGroup
-		)
-		sem := make(chan struct{}, concurrentLimit)
+// listFlag is a flag that may be given several times, collecting every
+// value.
+type listFlag []string
 
-		for _, ep := range sitemapURLs {
-			wg.Add(1)
-			sem <- struct{}{}
-			go func(ep string) {
-				defer wg.Done()
-				defer func() { <-sem }()
-				result, err := getSitemap(ep, concurrentLimit, timeout)
-				if err != nil {
-					fmt.Println(err)
-					return
-				}
-				mu.Lock()
-				pages = append(pages, result...)
-				mu.Unlock()
-			}(ep)
-		}
-		wg.Wait()
-
-		// Deduplicate across child sitemaps
-		seen := make(map[string]bool)
-		deduped := make([]string, 0, len(pages))
-		for _, p := range pages {
-			if !seen[p] {
-				seen[p] = true
-				deduped = append(deduped, p)
-			}
-		}
-		return deduped
-	} else if len(doc.Find("url").Nodes) > 0 {
-		return parseURLSet(doc)
-	}
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
 
-	fmt.Println("Empty result")
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
 	return nil
 }
+
+// confirmCrawl asks the user whether to go ahead with scraping the pages
+// that were found and checking their links.
+func confirmCrawl(pages int) bool {
+	var userContinue string
+	fmt.Fprint(console, "Continue verifying URLs? (y/n) ")
+	fmt.Scan(&userContinue)
+	fmt.Fprintln(console)
+	return strings.ToLower(userContinue) == "y"
+}
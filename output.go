@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"ewenson/sitemap_crawler/crawler"
+)
+
+// Report formats supported by -format.
+const (
+	formatCSV   = "csv"
+	formatLog   = "log"
+	formatJSON  = "json"
+	formatJSONL = "jsonl"
+	formatHTML  = "html"
+	formatJUnit = "junit"
+)
+
+func isReportFormat(format string) bool {
+	switch format {
+	case formatCSV, formatLog, formatJSON, formatJSONL, formatHTML, formatJUnit:
+		return true
+	}
+	return false
+}
+
+// output is where and in which format the report of a crawl is written.
+type output struct {
+	format string
+	// filename is the report file, "-" for stdout. When empty a file name
+	// is generated in ./logs once the report is written.
+	filename string
+	host     string
+	start    time.Time
+	// finish completes a report streamed while crawling, see stream.
+	finish func(*crawler.Report) error
+}
+
+// name returns the file the report is written to.
+func (o *output) name() string {
+	if o.filename != "" {
+		return o.filename
+	}
+	prefix, ext := "logs/report_", o.format
+	switch o.format {
+	case formatLog:
+		prefix = "logs/result_"
+	case formatJUnit:
+		ext = "xml"
+	}
+	return prefix + o.host + "_" + strconv.FormatInt(o.start.Unix(), 10) + "." + ext
+}
+
+// logFile returns filename, or if it is empty logs/<kind>_<host>.json after
+// creating the logs directory.
+func logFile(filename, kind, host string) (string, error) {
+	if filename != "" {
+		return filename, nil
+	}
+	if err := os.MkdirAll("./logs", 0755); err != nil {
+		return "", err
+	}
+	return "logs/" + kind + "_" + host + ".json", nil
+}
+
+// open creates the report file, or returns stdout for "-".
+func (o *output) open() (io.WriteCloser, error) {
+	if o.filename == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	if o.filename == "" {
+		if err := os.MkdirAll("./logs", 0755); err != nil {
+			return nil, err
+		}
+	}
+	o.filename = o.name()
+	return os.Create(o.filename)
+}
+
+// streams reports whether the report is written while the crawl runs.
+func (o *output) streams() bool {
+	return o.format == formatJSON || o.format == formatJSONL
+}
+
+// stream opens the report file and sets opts.OnResult to write every link
+// that was OK to it as soon as it is checked. Problems are only written by
+// write, once every page they were found on is known.
+func (o *output) stream(opts *crawler.Options) (io.Closer, error) {
+	w, err := o.open()
+	if err != nil {
+		return nil, err
+	}
+	var add func(crawler.LinkResult)
+	if o.format == formatJSON {
+		jw := crawler.NewJSONWriter(w)
+		add, o.finish = jw.Write, jw.Close
+	} else {
+		jw := crawler.NewJSONLWriter(w)
+		add = jw.Write
+		o.finish = func(report *crawler.Report) error {
+			for _, result := range report.LinkResults() {
+				jw.Write(result)
+			}
+			return jw.Err()
+		}
+	}
+	opts.OnResult = func(result crawler.LinkResult) {
+		if result.Category == crawler.CategoryOK {
+			add(result)
+		}
+	}
+	return w, nil
+}
+
+// always reports whether the format is written for every run, rather than
+// only when problems were found. Machine-readable formats are, so CI jobs
+// always find a report.
+func (o *output) always() bool {
+	return o.format == formatJSON || o.format == formatJSONL || o.format == formatJUnit
+}
+
+// write writes report in the configured format. CSV and log reports are
+// only written when problems were found. Streamed reports only have their
+// problems and totals left to write.
+func (o *output) write(report *crawler.Report) error {
+	if !o.always() && !hasProblems(report) {
+		return nil
+	}
+
+	switch {
+	case o.finish != nil:
+		return o.finish(report)
+	case o.format == formatLog:
+		return o.writeLog(report)
+	}
+
+	w, err := o.open()
+	if err != nil {
+		return err
+	}
+	switch o.format {
+	case formatJSON:
+		err = crawler.WriteJSON(w, report)
+	case formatHTML:
+		err = crawler.WriteHTML(w, report)
+	case formatJUnit:
+		err = crawler.WriteJUnit(w, report)
+	default:
+		err = crawler.WriteCSV(w, report)
+	}
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// writeLog writes every problem as a plain text log line.
+func (o *output) writeLog(report *crawler.Report) error {
+	w, err := o.open()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	logger := log.New(w, "", log.LstdFlags)
+
+	for _, e := range report.RequestErrors {
+		for _, origin := range e.Origins {
+			logger.Printf("%v (linked from %v with text %v)\n", e.Err, origin.URL, origin.Text)
+		}
+	}
+	for _, item := range report.Broken {
+		for _, origin := range item.Origins {
+			logger.Printf("HTTP %d for %s (linked from %s with text %s)\n", item.StatusCode, item.URL, origin.URL, origin.Text)
+		}
+	}
+	for _, item := range report.Warnings {
+		for _, warning := range item.Warnings {
+			for _, origin := range item.Origins {
+				logger.Printf("Warning for %s: %s (linked from %s with text %s)\n", item.URL, warning, origin.URL, origin.Text)
+			}
+		}
+	}
+	for _, m := range report.MissingAnchors {
+		for _, origin := range m.Origins {
+			logger.Printf("Missing anchor #%s in %s (linked from %s with text %s)\n", m.Fragment, m.URL, origin.URL, origin.Text)
+		}
+	}
+	return nil
+}
+
+func hasProblems(report *crawler.Report) bool {
+	return len(report.Broken) > 0 || len(report.RequestErrors) > 0 || len(report.Warnings) > 0 || len(report.MissingAnchors) > 0
+}
+
+// printSummary prints the totals of the crawl and where the report went.
+func printSummary(report *crawler.Report, out *output) {
+	numErrors := len(report.Broken)
+
+	fmt.Fprintln(console)
+	if len(report.RequestErrors) > 0 {
+		fmt.Fprintln(console, "Errors raised while checking URLs")
+	}
+	if len(report.Warnings) > 0 {
+		fmt.Fprintf(console, "%d links raised warnings\n", len(report.Warnings))
+	}
+	if len(report.MissingAnchors) > 0 {
+		fmt.Fprintf(console, "%d links point to anchors that do not exist\n", len(report.MissingAnchors))
+	}
+	if len(report.Suppressed) > 0 {
+		fmt.Fprintf(console, "%d accepted problems were left out according to the baseline\n", len(report.Suppressed))
+	}
+	if len(report.ExcludedPages) > 0 || len(report.ExcludedLinks) > 0 {
+		fmt.Fprintf(console, "%d pages and %d links were excluded by pattern\n", len(report.ExcludedPages), len(report.ExcludedLinks))
+	}
+	if len(report.RobotsSkipped) > 0 {
+		fmt.Fprintf(console, "%d pages were skipped because robots.txt disallows them\n", len(report.RobotsSkipped))
+	}
+	if len(report.Unchanged) > 0 {
+		fmt.Fprintf(console, "%d unchanged pages were not scraped again, their links were taken from the previous run\n", len(report.Unchanged))
+	}
+	if cached := report.Summary().Cached; cached > 0 {
+		fmt.Fprintf(console, "%d links were answered from the cache\n", cached)
+	}
+
+	fmt.Fprintf(console, "\nA total of %d links on %d pages was checked and %d produced errors of some sort.\n", report.Summary().Checked, len(report.Pages), numErrors)
+	fmt.Fprintln(console, "Total execution time:", report.Duration)
+
+	if out.filename == "-" || (!out.always() && !hasProblems(report)) {
+		return
+	}
+	switch {
+	case !hasProblems(report):
+		fmt.Fprintf(console, "\nNo errors found. Results saved to %v\n", out.filename)
+	case out.format == formatLog:
+		fmt.Fprintf(console, "\nErrors found. Check logfile (%v) for results.\n", out.filename)
+	default:
+		fmt.Fprintf(console, "\nErrors found. Results saved to %v\n", out.filename)
+	}
+}
+
+// nopCloser keeps stdout open when it is used as the report file.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }